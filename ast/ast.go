@@ -8,7 +8,7 @@ import (
 
 type Node interface {
 	Token_Literal() string // Returns the value of the associated token.Literal
-	Node_String() string   // A function that prints out the Node as a string
+	Node_String() string   // Prints the Node as source that parses back to an Equal node
 }
 
 type Statement interface {
@@ -21,6 +21,18 @@ type Expression interface {
 	Expression_Node()
 }
 
+// A Pattern is the target of a binding, e.g. `x`, `[head, ...tail]` or `{name, age: years}`
+type Pattern interface {
+	Node
+	Pattern_Node()
+}
+
+// A TypeExpr is a type annotation, e.g. `int`, `[int]`, `{string: int}` or `fn(int): bool`
+type TypeExpr interface {
+	Node
+	Type_Node()
+}
+
 // The entire program is an array of Statements
 type Program struct {
 	Statements []Statement
@@ -46,9 +58,16 @@ func (p *Program) Token_Literal() string {
 type Identifier struct {
 	Token token.Token // the token.IDENTIFIER token
 	Value string
+
+	// Filled in by package resolver: the identifier that declares this name, which is the
+	// identifier itself in a binding position, and how many scopes out the declaration is.
+	// Decl is nil for names that are not variables, such as keyword argument names, and for undefined names.
+	Decl  *Identifier
+	Depth int
 }
 
 func (id *Identifier) Expression_Node()      {}
+func (id *Identifier) Pattern_Node()         {}
 func (id *Identifier) Token_Literal() string { return id.Token.Literal }
 func (id *Identifier) Node_String() string {
 	var out bytes.Buffer
@@ -59,7 +78,8 @@ func (id *Identifier) Node_String() string {
 /** The LET Statement **/
 type LET_Statement struct {
 	Token token.Token // The 'LET' token
-	Name  *Identifier // The Identifier
+	Name  Pattern     // The Identifier, or an ARRAY_Pattern / HASH_Pattern when destructuring
+	Type  TypeExpr    // The annotated type of the value, nil if there is none
 	Value Expression
 }
 
@@ -67,7 +87,11 @@ func (ls *LET_Statement) Statement_Node()       {}
 func (ls *LET_Statement) Token_Literal() string { return ls.Token.Literal }
 func (ls *LET_Statement) Node_String() string {
 	var out bytes.Buffer
-	out.WriteString(ls.Token_Literal() + " " + ls.Name.Node_String() + " = ")
+	out.WriteString(ls.Token_Literal() + " " + ls.Name.Node_String())
+	if ls.Type != nil {
+		out.WriteString(": " + ls.Type.Node_String())
+	}
+	out.WriteString(" = ")
 	if ls.Value != nil {
 		out.WriteString(ls.Value.Node_String())
 	}
@@ -103,9 +127,9 @@ func (es *EXPRESSION_Statement) Statement_Node()       {}
 func (es *EXPRESSION_Statement) Token_Literal() string { return es.Token.Literal }
 func (es *EXPRESSION_Statement) Node_String() string {
 	if es.Expression != nil {
-		return es.Expression.Node_String()
+		return es.Expression.Node_String() + ";"
 	}
-	return ""
+	return ";"
 }
 
 /** INTEGER Literal **/
@@ -178,10 +202,10 @@ func (ie *IF_Expression) Expression_Node()      {}
 func (ie *IF_Expression) Token_Literal() string { return ie.Token.Literal }
 func (ie *IF_Expression) Node_String() string {
 	var out bytes.Buffer
-	out.WriteString(ie.Token_Literal() + " " + ie.Condition.Node_String() + " ")
+	out.WriteString(ie.Token_Literal() + " (" + ie.Condition.Node_String() + ") ")
 	out.WriteString(ie.Consequence.Node_String())
 	if ie.Alternative != nil {
-		out.WriteString("else")
+		out.WriteString(" else ")
 		out.WriteString(ie.Alternative.Node_String())
 	}
 	return out.String()
@@ -197,9 +221,122 @@ func (bs *BlockStatement) Statement_Node()       {}
 func (bs *BlockStatement) Token_Literal() string { return bs.Token.Literal }
 func (bs *BlockStatement) Node_String() string {
 	var out bytes.Buffer
+	out.WriteString("{")
 	for _, stmt := range bs.Statemens {
+		out.WriteString(" ")
 		out.WriteString(stmt.Node_String())
 	}
+	out.WriteString(" }")
+	return out.String()
+}
+
+/** ARRAY Pattern **/
+type ARRAY_Pattern struct {
+	Token    token.Token // the '[' token
+	Elements []Pattern
+	Rest     *Identifier // Binds the remaining elements, nil if there is no `...rest`
+}
+
+func (ap *ARRAY_Pattern) Pattern_Node()         {}
+func (ap *ARRAY_Pattern) Token_Literal() string { return ap.Token.Literal }
+func (ap *ARRAY_Pattern) Node_String() string {
+	var out bytes.Buffer
+	elements := []string{}
+	for _, el := range ap.Elements {
+		elements = append(elements, el.Node_String())
+	}
+	if ap.Rest != nil {
+		elements = append(elements, "..."+ap.Rest.Node_String())
+	}
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+	return out.String()
+}
+
+/** HASH Pattern **/
+type HASH_Pattern struct {
+	Token   token.Token // the '{' token
+	Entries []*HASH_PatternEntry
+}
+
+func (hp *HASH_Pattern) Pattern_Node()         {}
+func (hp *HASH_Pattern) Token_Literal() string { return hp.Token.Literal }
+func (hp *HASH_Pattern) Node_String() string {
+	var out bytes.Buffer
+	entries := []string{}
+	for _, en := range hp.Entries {
+		entries = append(entries, en.Node_String())
+	}
+	out.WriteString("{")
+	out.WriteString(strings.Join(entries, ", "))
+	out.WriteString("}")
+	return out.String()
+}
+
+// One `key: pattern` entry of a HASH_Pattern; `{name}` is short for `{name: name}`
+type HASH_PatternEntry struct {
+	Token token.Token // the key's IDENTIFIER token
+	Key   *Identifier
+	Value Pattern
+}
+
+func (he *HASH_PatternEntry) Token_Literal() string { return he.Token.Literal }
+func (he *HASH_PatternEntry) Node_String() string {
+	if id, ok := he.Value.(*Identifier); ok && id.Value == he.Key.Value {
+		return he.Key.Node_String()
+	}
+	return he.Key.Node_String() + ": " + he.Value.Node_String()
+}
+
+/** WILDCARD Pattern **/
+type WILDCARD_Pattern struct {
+	Token token.Token // the '_' token
+}
+
+func (wp *WILDCARD_Pattern) Pattern_Node()         {}
+func (wp *WILDCARD_Pattern) Token_Literal() string { return wp.Token.Literal }
+func (wp *WILDCARD_Pattern) Node_String() string   { return wp.Token.Literal }
+
+/** LITERAL Pattern **/
+type LITERAL_Pattern struct {
+	Token token.Token // the first token of the literal
+	Value Expression  // An INTEGER_Literal, a Boolean or a negated INTEGER_Literal
+}
+
+func (lp *LITERAL_Pattern) Pattern_Node()         {}
+func (lp *LITERAL_Pattern) Token_Literal() string { return lp.Token.Literal }
+func (lp *LITERAL_Pattern) Node_String() string {
+	// a negative literal is written `-1`, the parentheses of PREFIX_Expression are not a pattern
+	if pe, ok := lp.Value.(*PREFIX_Expression); ok {
+		return pe.Token_Literal() + pe.Right.Node_String()
+	}
+	return lp.Value.Node_String()
+}
+
+/** Function Parameter **/
+type Parameter struct {
+	Token    token.Token // the parameter's first token, or the '...' token of a rest parameter
+	Name     Pattern     // Always an *Identifier for a rest parameter
+	Type     TypeExpr    // The annotated type, nil if there is none; for a rest parameter the type of the array it collects
+	Default  Expression  // The default value, nil if the parameter is required
+	Variadic bool        // Whether the parameter collects the remaining positional arguments
+}
+
+func (pa *Parameter) Token_Literal() string { return pa.Token.Literal }
+func (pa *Parameter) Node_String() string {
+	var out bytes.Buffer
+	if pa.Variadic {
+		out.WriteString("...")
+	}
+	out.WriteString(pa.Name.Node_String())
+	if pa.Type != nil {
+		out.WriteString(": " + pa.Type.Node_String())
+	}
+	if pa.Default != nil {
+		out.WriteString(" = ")
+		out.WriteString(pa.Default.Node_String())
+	}
 	return out.String()
 }
 
@@ -207,7 +344,8 @@ func (bs *BlockStatement) Node_String() string {
 /** Function Literals **/
 type FunctionLiteral struct {
 	Token      token.Token // the 'function' token
-	Parameters []*Identifier
+	Parameters []*Parameter
+	ReturnType TypeExpr // The annotated result type, nil if there is none
 	Body       *BlockStatement
 }
 
@@ -219,14 +357,38 @@ func (fl *FunctionLiteral) Node_String() string {
 	for _, p := range fl.Parameters {
 		params = append(params, p.Node_String())
 	}
+	// arrow functions get an "fn" token too, so they print as the literal they stand for
 	out.WriteString(fl.Token_Literal())
 	out.WriteString("(")
-	out.WriteString(strings.Join(params, ","))
+	out.WriteString(strings.Join(params, ", "))
 	out.WriteString(")")
+	if fl.ReturnType != nil {
+		out.WriteString(": " + fl.ReturnType.Node_String())
+	}
+	out.WriteString(" ")
 	out.WriteString(fl.Body.Node_String())
 	return out.String()
 }
 
+/** MACRO Literals **/
+// A macro is expanded before the program runs: a call to it is replaced by the code its body
+// quotes, with the unevaluated arguments in place of the parameters. See package macro.
+type MacroLiteral struct {
+	Token      token.Token // the 'macro' token
+	Parameters []*Identifier
+	Body       *BlockStatement
+}
+
+func (ml *MacroLiteral) Expression_Node()      {}
+func (ml *MacroLiteral) Token_Literal() string { return ml.Token.Literal }
+func (ml *MacroLiteral) Node_String() string {
+	params := []string{}
+	for _, p := range ml.Parameters {
+		params = append(params, p.Node_String())
+	}
+	return ml.Token_Literal() + "(" + strings.Join(params, ", ") + ") " + ml.Body.Node_String()
+}
+
 /** CALL Expressions **/
 type CALL_Expression struct {
 	Token     token.Token // the '(' token
@@ -244,7 +406,123 @@ func (ce *CALL_Expression) Node_String() string {
 	}
 	out.WriteString(ce.Function.Node_String())
 	out.WriteString("(")
-	out.WriteString(strings.Join(args, ","))
+	out.WriteString(strings.Join(args, ", "))
 	out.WriteString(")")
 	return out.String()
 }
+
+/** KEYWORD Argument **/
+type KEYWORD_Argument struct {
+	Token token.Token // the IDENTIFIER token naming the parameter
+	Name  *Identifier
+	Value Expression
+}
+
+func (ka *KEYWORD_Argument) Expression_Node()      {}
+func (ka *KEYWORD_Argument) Token_Literal() string { return ka.Token.Literal }
+func (ka *KEYWORD_Argument) Node_String() string {
+	var out bytes.Buffer
+	out.WriteString(ka.Name.Node_String())
+	out.WriteString(": ")
+	out.WriteString(ka.Value.Node_String())
+	return out.String()
+}
+
+/** MATCH Expression **/
+type MATCH_Expression struct {
+	Token   token.Token // the 'match' token
+	Subject Expression  // The value being matched
+	Arms    []*MATCH_Arm
+}
+
+func (me *MATCH_Expression) Expression_Node()      {}
+func (me *MATCH_Expression) Token_Literal() string { return me.Token.Literal }
+func (me *MATCH_Expression) Node_String() string {
+	var out bytes.Buffer
+	arms := []string{}
+	for _, arm := range me.Arms {
+		arms = append(arms, arm.Node_String())
+	}
+	out.WriteString(me.Token_Literal() + " (" + me.Subject.Node_String() + ") {")
+	out.WriteString(strings.Join(arms, ", "))
+	out.WriteString("}")
+	return out.String()
+}
+
+// One `pattern if guard => body` arm of a MATCH_Expression
+type MATCH_Arm struct {
+	Token   token.Token // the first token of the pattern
+	Pattern Pattern
+	Guard   Expression // nil if the arm has no `if` guard
+	Body    Expression
+}
+
+func (ma *MATCH_Arm) Token_Literal() string { return ma.Token.Literal }
+func (ma *MATCH_Arm) Node_String() string {
+	var out bytes.Buffer
+	out.WriteString(ma.Pattern.Node_String())
+	if ma.Guard != nil {
+		out.WriteString(" if " + ma.Guard.Node_String())
+	}
+	out.WriteString(" => ")
+	out.WriteString(ma.Body.Node_String())
+	return out.String()
+}
+
+/** TYPE Name **/
+type TYPE_Name struct {
+	Token token.Token // the IDENTIFIER token
+	Value string      // e.g. int, bool or string
+}
+
+func (tn *TYPE_Name) Type_Node()            {}
+func (tn *TYPE_Name) Token_Literal() string { return tn.Token.Literal }
+func (tn *TYPE_Name) Node_String() string   { return tn.Value }
+
+/** ARRAY Type **/
+type ARRAY_Type struct {
+	Token   token.Token // the '[' token
+	Element TypeExpr
+}
+
+func (at *ARRAY_Type) Type_Node()            {}
+func (at *ARRAY_Type) Token_Literal() string { return at.Token.Literal }
+func (at *ARRAY_Type) Node_String() string {
+	return "[" + at.Element.Node_String() + "]"
+}
+
+/** HASH Type **/
+type HASH_Type struct {
+	Token token.Token // the '{' token
+	Key   TypeExpr
+	Value TypeExpr
+}
+
+func (ht *HASH_Type) Type_Node()            {}
+func (ht *HASH_Type) Token_Literal() string { return ht.Token.Literal }
+func (ht *HASH_Type) Node_String() string {
+	return "{" + ht.Key.Node_String() + ": " + ht.Value.Node_String() + "}"
+}
+
+/** FUNCTION Type **/
+type FUNCTION_Type struct {
+	Token      token.Token // the 'fn' token
+	Parameters []TypeExpr
+	Return     TypeExpr
+}
+
+func (ft *FUNCTION_Type) Type_Node()            {}
+func (ft *FUNCTION_Type) Token_Literal() string { return ft.Token.Literal }
+func (ft *FUNCTION_Type) Node_String() string {
+	var out bytes.Buffer
+	params := []string{}
+	for _, p := range ft.Parameters {
+		params = append(params, p.Node_String())
+	}
+	out.WriteString(ft.Token_Literal())
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString("): ")
+	out.WriteString(ft.Return.Node_String())
+	return out.String()
+}
@@ -0,0 +1,127 @@
+package ast
+
+import "fmt"
+
+// Arity returns the minimum and maximum number of arguments the function accepts.
+// max is -1 when the function has a rest parameter.
+func (fl *FunctionLiteral) Arity() (min int, max int) {
+	for _, p := range fl.Parameters {
+		if p.Variadic {
+			return min, -1
+		}
+		if p.Default == nil {
+			min++
+		}
+		max++
+	}
+	return min, max
+}
+
+// A Binding pairs a parameter with the arguments that fill it
+type Binding struct {
+	Parameter *Parameter
+	Values    []Expression // empty when the parameter falls back to its Default
+}
+
+// BindArguments matches the arguments of a call against the parameters of the function.
+// Positional arguments fill the parameters in order, the rest parameter collects whatever is left
+// and KEYWORD_Arguments fill the parameter with the same name. Destructuring parameters can only
+// be filled by position.
+func (fl *FunctionLiteral) BindArguments(args []Expression) ([]Binding, error) {
+	bindings := make([]Binding, len(fl.Parameters))
+	filled := make([]bool, len(fl.Parameters))
+	for i, p := range fl.Parameters {
+		bindings[i].Parameter = p
+	}
+	min, max := fl.Arity()
+
+	positional := 0
+	for _, arg := range args {
+		if _, ok := arg.(*KEYWORD_Argument); !ok {
+			positional++
+		}
+	}
+	if max >= 0 && positional > max {
+		return nil, arityError(min, max, positional)
+	}
+
+	next := 0
+	for _, arg := range args {
+		if kw, ok := arg.(*KEYWORD_Argument); ok {
+			i := fl.parameterIndex(kw.Name.Value)
+			if i < 0 {
+				return nil, fmt.Errorf("unexpected keyword argument %s", kw.Name.Value)
+			}
+			if fl.Parameters[i].Variadic {
+				return nil, fmt.Errorf("rest parameter %s cannot be passed by keyword", kw.Name.Value)
+			}
+			if filled[i] {
+				return nil, fmt.Errorf("multiple values for parameter %s", kw.Name.Value)
+			}
+			bindings[i].Values = []Expression{kw.Value}
+			filled[i] = true
+			continue
+		}
+		if fl.Parameters[next].Variadic {
+			bindings[next].Values = append(bindings[next].Values, arg)
+			continue
+		}
+		bindings[next].Values = []Expression{arg}
+		filled[next] = true
+		next++
+	}
+
+	for i, p := range fl.Parameters {
+		if !filled[i] && !p.Variadic && p.Default == nil {
+			if len(args) < min {
+				return nil, arityError(min, max, len(args))
+			}
+			return nil, fmt.Errorf("missing argument for parameter %s", p.Name.Node_String())
+		}
+	}
+	return bindings, nil
+}
+
+func (fl *FunctionLiteral) parameterIndex(name string) int {
+	for i, p := range fl.Parameters {
+		if id, ok := p.Name.(*Identifier); ok && id.Value == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func arityError(min, max, got int) error {
+	switch {
+	case min == max:
+		return fmt.Errorf("wrong number of arguments: want %d, got %d", min, got)
+	case max < 0 || got < min:
+		return fmt.Errorf("wrong number of arguments: want at least %d, got %d", min, got)
+	default:
+		return fmt.Errorf("wrong number of arguments: want at most %d, got %d", max, got)
+	}
+}
+
+// BoundIdentifiers returns the identifiers a pattern binds, in source order
+func BoundIdentifiers(pattern Pattern) []*Identifier {
+	switch p := pattern.(type) {
+	case *Identifier:
+		return []*Identifier{p}
+	case *ARRAY_Pattern:
+		ids := []*Identifier{}
+		for _, el := range p.Elements {
+			ids = append(ids, BoundIdentifiers(el)...)
+		}
+		if p.Rest != nil {
+			ids = append(ids, p.Rest)
+		}
+		return ids
+	case *HASH_Pattern:
+		ids := []*Identifier{}
+		for _, en := range p.Entries {
+			ids = append(ids, BoundIdentifiers(en.Value)...)
+		}
+		return ids
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package ast
+
+import "reflect"
+
+// Copy returns a deep copy of node, so that either can be modified alone. A node that appears
+// twice in node, such as the key and value of a shorthand hash pattern entry, is copied once and
+// appears twice in the copy. The Decl and Depth of identifiers are not copied; run the resolver
+// on the copy to fill them in.
+func Copy(node Node) Node {
+	if node == nil || isNilNode(node) {
+		return node
+	}
+	copies := map[uintptr]reflect.Value{}
+	return copyValue(reflect.ValueOf(node), copies).Interface().(Node)
+}
+
+// Helper function that copies the pointers, slices and interfaces in v, reusing the copy of a
+// pointer that was seen before
+func copyValue(v reflect.Value, copies map[uintptr]reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		if c, ok := copies[v.Pointer()]; ok {
+			return c
+		}
+		c := reflect.New(v.Elem().Type())
+		copies[v.Pointer()] = c
+		c.Elem().Set(v.Elem())
+		if c.Elem().Kind() != reflect.Struct {
+			return c
+		}
+		for i := 0; i < c.Elem().NumField(); i++ {
+			field := c.Elem().Field(i)
+			switch c.Elem().Type().Field(i).Name {
+			case "Decl", "Depth":
+				field.Set(reflect.Zero(field.Type()))
+			default:
+				field.Set(copyValue(field, copies))
+			}
+		}
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(copyValue(v.Index(i), copies))
+		}
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(copyValue(v.Elem(), copies))
+		return c
+	}
+	return v
+}
@@ -0,0 +1,146 @@
+package ast
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteDOT renders node and everything under it as a Graphviz DOT graph. Each node is labelled
+// with its type and Token_Literal, and each edge with the field that holds the child,
+// e.g. Left, Consequence or Arguments[1].
+func WriteDOT(w io.Writer, node Node) error {
+	out := bufio.NewWriter(w)
+	out.WriteString("digraph AST {\n")
+	out.WriteString("\tnode [shape=box, fontname=\"monospace\"];\n")
+	ids := 0
+	var write func(node Node) int
+	write = func(node Node) int {
+		id := ids
+		ids++
+		label := strings.TrimPrefix(fmt.Sprintf("%T", node), "*ast.")
+		if _, isProgram := node.(*Program); !isProgram && node.Token_Literal() != "" {
+			label += "\n" + node.Token_Literal()
+		}
+		fmt.Fprintf(out, "\tn%d [label=\"%s\"];\n", id, dotEscape(label))
+		for _, child := range fieldsOf(node) {
+			childID := write(child.node)
+			fmt.Fprintf(out, "\tn%d -> n%d [label=\"%s\"];\n", id, childID, dotEscape(child.name))
+		}
+		return id
+	}
+	write(node)
+	out.WriteString("}\n")
+	return out.Flush()
+}
+
+// Helper function that escapes a string for a quoted DOT label; \n stays a line break
+func dotEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return strings.ReplaceAll(s, "\n", `\n`)
+}
+
+// A field is a non-nil child of a node along with the name of the field that holds it
+type field struct {
+	name string
+	node Node
+}
+
+// Helper function that lists the children of a node in the same order as Walk
+func fieldsOf(node Node) []field {
+	fields := []field{}
+	add := func(name string, child Node) {
+		if child != nil && !isNilNode(child) {
+			fields = append(fields, field{name, child})
+		}
+	}
+	addStatements := func(name string, list []Statement) {
+		for i, stmt := range list {
+			add(fmt.Sprintf("%s[%d]", name, i), stmt)
+		}
+	}
+	switch n := node.(type) {
+	case *Program:
+		addStatements("Statements", n.Statements)
+	case *LET_Statement:
+		add("Name", n.Name)
+		add("Type", n.Type)
+		add("Value", n.Value)
+	case *RETURN_Statement:
+		add("ReturnValue", n.ReturnValue)
+	case *EXPRESSION_Statement:
+		add("Expression", n.Expression)
+	case *BlockStatement:
+		addStatements("Statemens", n.Statemens)
+	case *PREFIX_Expression:
+		add("Right", n.Right)
+	case *INFIX_Expression:
+		add("Left", n.Left)
+		add("Right", n.Right)
+	case *IF_Expression:
+		add("Condition", n.Condition)
+		add("Consequence", n.Consequence)
+		add("Alternative", n.Alternative)
+	case *ARRAY_Pattern:
+		for i, el := range n.Elements {
+			add(fmt.Sprintf("Elements[%d]", i), el)
+		}
+		add("Rest", n.Rest)
+	case *HASH_Pattern:
+		for i, en := range n.Entries {
+			add(fmt.Sprintf("Entries[%d]", i), en)
+		}
+	case *HASH_PatternEntry:
+		add("Key", n.Key)
+		if n.Value != Pattern(n.Key) {
+			add("Value", n.Value)
+		}
+	case *LITERAL_Pattern:
+		add("Value", n.Value)
+	case *Parameter:
+		add("Name", n.Name)
+		add("Type", n.Type)
+		add("Default", n.Default)
+	case *FunctionLiteral:
+		for i, p := range n.Parameters {
+			add(fmt.Sprintf("Parameters[%d]", i), p)
+		}
+		add("ReturnType", n.ReturnType)
+		add("Body", n.Body)
+	case *MacroLiteral:
+		for i, p := range n.Parameters {
+			add(fmt.Sprintf("Parameters[%d]", i), p)
+		}
+		add("Body", n.Body)
+	case *CALL_Expression:
+		add("Function", n.Function)
+		for i, arg := range n.Arguments {
+			add(fmt.Sprintf("Arguments[%d]", i), arg)
+		}
+	case *KEYWORD_Argument:
+		add("Name", n.Name)
+		add("Value", n.Value)
+	case *MATCH_Expression:
+		add("Subject", n.Subject)
+		for i, arm := range n.Arms {
+			add(fmt.Sprintf("Arms[%d]", i), arm)
+		}
+	case *MATCH_Arm:
+		add("Pattern", n.Pattern)
+		add("Guard", n.Guard)
+		add("Body", n.Body)
+	case *ARRAY_Type:
+		add("Element", n.Element)
+	case *HASH_Type:
+		add("Key", n.Key)
+		add("Value", n.Value)
+	case *FUNCTION_Type:
+		for i, p := range n.Parameters {
+			add(fmt.Sprintf("Parameters[%d]", i), p)
+		}
+		add("Return", n.Return)
+	}
+	return fields
+}
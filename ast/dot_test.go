@@ -0,0 +1,77 @@
+package ast_test
+
+import (
+	"bytes"
+	"monkey/ast"
+	"strings"
+	"testing"
+)
+
+func TestWriteDOT(t *testing.T) {
+	var out bytes.Buffer
+	if err := ast.WriteDOT(&out, parse(t, "f(a, 1 + 2)")); err != nil {
+		t.Fatal(err)
+	}
+	want := `digraph AST {
+	node [shape=box, fontname="monospace"];
+	n0 [label="Program"];
+	n1 [label="EXPRESSION_Statement\nf"];
+	n2 [label="CALL_Expression\n("];
+	n3 [label="Identifier\nf"];
+	n2 -> n3 [label="Function"];
+	n4 [label="Identifier\na"];
+	n2 -> n4 [label="Arguments[0]"];
+	n5 [label="INFIX_Expression\n+"];
+	n6 [label="INTEGER_Literal\n1"];
+	n5 -> n6 [label="Left"];
+	n7 [label="INTEGER_Literal\n2"];
+	n5 -> n7 [label="Right"];
+	n2 -> n5 [label="Arguments[1]"];
+	n1 -> n2 [label="Expression"];
+	n0 -> n1 [label="Statements[0]"];
+}
+`
+	if out.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestWriteDOTEdges(t *testing.T) {
+	tests := []struct {
+		input string
+		edges []string // edge labels in the order they are written
+	}{
+		{"if (x) { 1 } else { 2 }", []string{"Condition", "Expression", "Statemens[0]", "Consequence", "Expression", "Statemens[0]", "Alternative", "Expression", "Statements[0]"}},
+		{"if (x) { 1 }", []string{"Condition", "Expression", "Statemens[0]", "Consequence", "Expression", "Statements[0]"}},
+		{"let {a} = b;", []string{"Key", "Entries[0]", "Name", "Value", "Statements[0]"}},
+		{"fn(a = 1) { a }", []string{"Name", "Default", "Parameters[0]", "Expression", "Statemens[0]", "Body", "Expression", "Statements[0]"}},
+		{"match (x) { _ if y => 1 }", []string{"Subject", "Pattern", "Guard", "Body", "Arms[0]", "Expression", "Statements[0]"}},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if err := ast.WriteDOT(&out, parse(t, tt.input)); err != nil {
+			t.Fatal(err)
+		}
+		edges := []string{}
+		for _, line := range strings.Split(out.String(), "\n") {
+			if i := strings.Index(line, `[label="`); i >= 0 && strings.Contains(line, "->") {
+				edges = append(edges, strings.TrimSuffix(line[i+len(`[label="`):], `"];`))
+			}
+		}
+		if strings.Join(edges, " ") != strings.Join(tt.edges, " ") {
+			t.Errorf("%q: edges %v, want %v", tt.input, edges, tt.edges)
+		}
+	}
+}
+
+func TestWriteDOTEscapes(t *testing.T) {
+	id := &ast.Identifier{Value: `a"b\c`}
+	id.Token.Literal = `a"b\c`
+	var out bytes.Buffer
+	if err := ast.WriteDOT(&out, id); err != nil {
+		t.Fatal(err)
+	}
+	if want := `n0 [label="Identifier\na\"b\\c"];`; !strings.Contains(out.String(), want) {
+		t.Errorf("got\n%s\nwant a line %s", out.String(), want)
+	}
+}
@@ -0,0 +1,52 @@
+package ast
+
+import "reflect"
+
+// Equal reports whether a and b are the same tree: nodes of the same types, holding the same
+// names, operators and values, with Equal children. Tokens, and so positions, are not compared,
+// which also makes an arrow function Equal to the fn literal it stands for and `{a: a}` Equal to `{a}`.
+func Equal(a, b Node) bool {
+	aNil, bNil := a == nil || isNilNode(a), b == nil || isNilNode(b)
+	if aNil || bNil {
+		return aNil == bNil
+	}
+	if reflect.TypeOf(a) != reflect.TypeOf(b) || !sameValue(a, b) {
+		return false
+	}
+	// fieldsOf leaves out the Value of a shorthand entry
+	if ea, ok := a.(*HASH_PatternEntry); ok {
+		eb := b.(*HASH_PatternEntry)
+		return Equal(ea.Key, eb.Key) && Equal(ea.Value, eb.Value)
+	}
+	fa, fb := fieldsOf(a), fieldsOf(b)
+	if len(fa) != len(fb) {
+		return false
+	}
+	for i := range fa {
+		if fa[i].name != fb[i].name || !Equal(fa[i].node, fb[i].node) {
+			return false
+		}
+	}
+	return true
+}
+
+// Helper function that compares what two nodes of the same type hold besides their children
+func sameValue(a, b Node) bool {
+	switch a := a.(type) {
+	case *Identifier:
+		return a.Value == b.(*Identifier).Value
+	case *INTEGER_Literal:
+		return a.Value == b.(*INTEGER_Literal).Value
+	case *Boolean:
+		return a.Value == b.(*Boolean).Value
+	case *PREFIX_Expression:
+		return a.Token.Type == b.(*PREFIX_Expression).Token.Type
+	case *INFIX_Expression:
+		return a.Token.Type == b.(*INFIX_Expression).Token.Type
+	case *Parameter:
+		return a.Variadic == b.(*Parameter).Variadic
+	case *TYPE_Name:
+		return a.Value == b.(*TYPE_Name).Value
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+package ast_test
+
+import (
+	"monkey/ast"
+	"monkey/lexer"
+	"monkey/parser"
+	"testing"
+)
+
+// Between them the sources hold every kind of node, so printing each one is checked to parse back
+var roundTripSources = []string{
+	"let x = 5; return x;",
+	"let x = 5; x",
+	"-a * b + !c / d - e",
+	"a < b == b > c != true; false",
+	"(a + b) * (c - d)",
+	"-(-1)",
+	"!!true",
+	"if (a) { b }",
+	"if (a) { b; c } else { let d = e; d }",
+	"if (if (a) { b } else { c }) { 1 } else { if (d) { 2 } }",
+	"fn() {}",
+	"fn() { return 1; }",
+	"fn(x, y = x + 1, ...rest) { x }",
+	"fn([a, [b, c], ...d], {e, f: [g], h: {i}} = x) { a }",
+	"fn(x) { fn(y) { x + y } }(1)(2)",
+	"f(1, b: 2, c: x + 1)",
+	"f(g(h(1)), fn(x) { x })",
+	"(x, y) => x + y",
+	"x => y => x * y",
+	"() => { let y = 1; y }",
+	"x |> f |> g(1, k: 2) |> (y => y)",
+	"let [head, ...tail] = xs;",
+	"let {name, age: years} = person;",
+	"match (x) { 1 => 10, -1 => 20, true => 30, false => 31, _ => 40 }",
+	"match (xs) { [] => 0, [1, _] => 1, [head, ...tail] if head > 0 => head, [...all] => 2 }",
+	"match (p) { {kind: 1, size} => size, {kind: [_, k]} if (m => m)(k) => 0, {} => 1 }",
+	"match (match (a) { _ => b }) { n => match (n) { _ => n } }",
+	"let x: int = 1; let [a, b]: [bool] = xs;",
+	"let f: fn(fn(int): bool, {string: [int]}): fn(): int = fn(a: int = 1, ...r: [int]): bool { true };",
+}
+
+func TestNodeStringRoundTrip(t *testing.T) {
+	for _, src := range roundTripSources {
+		program := parse(t, src)
+		printed := program.Node_String()
+		ps := parser.New(lexer.New(printed))
+		reparsed := ps.ParseProgram()
+		if errs := ps.Errors(); len(errs) > 0 {
+			t.Errorf("%q printed as %q, which does not parse: %s", src, printed, errs)
+			continue
+		}
+		if !ast.Equal(program, reparsed) {
+			t.Errorf("%q printed as %q, which parses to %q", src, printed, reparsed.Node_String())
+			continue
+		}
+		// printing is stable once the source has been through it
+		if again := reparsed.Node_String(); again != printed {
+			t.Errorf("%q printed as %q, then as %q", src, printed, again)
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"let x = 1;", "let   x=1", true},
+		{"x => x", "fn(x) { x }", true},
+		{"let {a: a} = p;", "let {a} = p;", true},
+		{"x |> f(1)", "f(x, 1)", true},
+		{"let x = 1;", "let y = 1;", false},
+		{"1 + 2", "1 - 2", false},
+		{"1 + 2", "2 + 1", false},
+		{"-a", "!a", false},
+		{"true", "false", false},
+		{"fn(x) { x }", "fn(...x) { x }", false},
+		{"fn(x) { x }", "fn(x = 1) { x }", false},
+		{"if (a) { b }", "if (a) { b } else { b }", false},
+		{"f(a, b)", "f(a, b: b)", false},
+		{"match (x) { _ => 1 }", "match (x) { y => 1 }", false},
+		{"match (x) { n if n => 1 }", "match (x) { n => 1 }", false},
+		{"a; b", "a", false},
+	}
+	for _, tt := range tests {
+		if got := ast.Equal(parse(t, tt.a), parse(t, tt.b)); got != tt.want {
+			t.Errorf("Equal(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+	if !ast.Equal(nil, nil) || ast.Equal(parse(t, "a"), nil) {
+		t.Errorf("Equal mishandles nil nodes")
+	}
+}
@@ -0,0 +1,65 @@
+package ast
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"strconv"
+)
+
+// Hash returns a structural hash of node that agrees with Equal: Equal nodes have the same hash.
+// It depends only on the shape and contents of the tree, never on positions or pointers,
+// so it is the same from one run to the next and can key caches that outlive a parse.
+func Hash(node Node) uint64 {
+	h := fnv.New64a()
+	writeHash(h, node)
+	return h.Sum64()
+}
+
+// Helper function that feeds node to h as its type, its value, then each named child between
+// brackets, so that different trees never write the same sequence
+func writeHash(h hash.Hash64, node Node) {
+	if node == nil || isNilNode(node) {
+		h.Write([]byte("nil;"))
+		return
+	}
+	fmt.Fprintf(h, "%T(", node)
+	switch n := node.(type) {
+	case *Identifier:
+		writeString(h, n.Value)
+	case *INTEGER_Literal:
+		binary.Write(h, binary.BigEndian, n.Value)
+	case *Boolean:
+		h.Write([]byte(strconv.FormatBool(n.Value)))
+	case *PREFIX_Expression:
+		writeString(h, string(n.Token.Type))
+	case *INFIX_Expression:
+		writeString(h, string(n.Token.Type))
+	case *Parameter:
+		h.Write([]byte(strconv.FormatBool(n.Variadic)))
+	case *TYPE_Name:
+		writeString(h, n.Value)
+	}
+	h.Write([]byte("|"))
+	// a shorthand entry hashes like the `key: key` it is short for, as in Equal
+	if en, ok := node.(*HASH_PatternEntry); ok {
+		writeChild(h, "Key", en.Key)
+		writeChild(h, "Value", en.Value)
+	} else {
+		for _, child := range fieldsOf(node) {
+			writeChild(h, child.name, child.node)
+		}
+	}
+	h.Write([]byte(")"))
+}
+
+func writeChild(h hash.Hash64, name string, node Node) {
+	writeString(h, name)
+	writeHash(h, node)
+}
+
+// Helper function that writes a string prefixed with its length, so that it cannot run into what follows
+func writeString(h hash.Hash64, s string) {
+	fmt.Fprintf(h, "%d:%s", len(s), s)
+}
@@ -0,0 +1,57 @@
+package ast_test
+
+import (
+	"monkey/ast"
+	"testing"
+)
+
+func TestHashAgreesWithEqual(t *testing.T) {
+	tests := []struct{ a, b string }{
+		{"let x = 1;", "let   x=1"},
+		{"x => x", "fn(x) { x }"},
+		{"let {a: a} = p;", "let {a} = p;"},
+		{"x |> f(1)", "f(x, 1)"},
+		{"match (x) { -1 => 0 }", "match (x) {\n  -1 => 0,\n}"},
+	}
+	for _, tt := range tests {
+		a, b := parse(t, tt.a), parse(t, tt.b)
+		if !ast.Equal(a, b) {
+			t.Fatalf("%q and %q are not Equal", tt.a, tt.b)
+		}
+		if ast.Hash(a) != ast.Hash(b) {
+			t.Errorf("Equal trees %q and %q hash differently", tt.a, tt.b)
+		}
+	}
+}
+
+func TestHashDistinguishesTrees(t *testing.T) {
+	sources := append([]string{
+		"1 + 2", "2 + 1", "1 - 2", "-a", "!a", "a", "b", "ab",
+		"fn(x) { x }", "fn(...x) { x }", "fn(x = 1) { x }",
+		"f(a, b)", "f(a, b: b)", "f(a)(b)", "f(a(b))",
+		"a; b", "b; a",
+		"match (x) { _ => 1 }", "match (x) { y => 1 }", "match (x) { n if n => 1 }",
+	}, roundTripSources...)
+	seen := map[uint64]string{}
+	for _, src := range sources {
+		h := ast.Hash(parse(t, src))
+		if other, ok := seen[h]; ok {
+			t.Errorf("%q and %q have the same hash %x", src, other, h)
+		}
+		seen[h] = src
+	}
+}
+
+func TestHashIsStable(t *testing.T) {
+	// the hash is a function of the tree alone, so parsing the same source again or hashing the
+	// same subtree inside another program gives the same value
+	body := "fn(x, y) { if (x > y) { x } else { y } }"
+	first, second := parse(t, body), parse(t, "let max = "+body+";")
+	inner := second.Statements[0].(*ast.LET_Statement).Value
+	if ast.Hash(first.Statements[0].(*ast.EXPRESSION_Statement).Expression) != ast.Hash(inner) {
+		t.Errorf("the same function literal hashes differently in two programs")
+	}
+	if ast.Hash(nil) != ast.Hash(nil) {
+		t.Errorf("Hash(nil) is not stable")
+	}
+}
@@ -0,0 +1,491 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"monkey/token"
+	"reflect"
+)
+
+// EncodeJSON encodes node and everything under it as JSON. Every node is an object whose "kind"
+// is its type name, e.g. "INFIX_Expression", followed by its token and its fields in declaration order.
+func EncodeJSON(node Node) ([]byte, error) {
+	return json.Marshal(encodeNode(node))
+}
+
+// DecodeJSON rebuilds a tree encoded by EncodeJSON
+func DecodeJSON(data []byte) (Node, error) {
+	node, err := decodeNode(data)
+	if err == nil && node == nil {
+		return nil, fmt.Errorf("null is not a node")
+	}
+	return node, err
+}
+
+/** Encoding **/
+
+// A jsonObject keeps its fields in order so that the output is stable and starts with the kind
+type jsonObject []jsonField
+
+type jsonField struct {
+	key   string
+	value interface{}
+}
+
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	var out bytes.Buffer
+	out.WriteString("{")
+	for i, f := range o {
+		if i > 0 {
+			out.WriteString(",")
+		}
+		key, _ := json.Marshal(f.key)
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		out.Write(key)
+		out.WriteString(":")
+		out.Write(value)
+	}
+	out.WriteString("}")
+	return out.Bytes(), nil
+}
+
+type jsonToken struct {
+	Type    token.TokenType `json:"type"`
+	Literal string          `json:"literal"`
+	Pos     jsonPosition    `json:"pos"`
+}
+
+type jsonPosition struct {
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+func encodeToken(tok token.Token) jsonToken {
+	return jsonToken{tok.Type, tok.Literal, jsonPosition{tok.Pos.Offset, tok.Pos.Line, tok.Pos.Column}}
+}
+
+func encodeNode(node Node) interface{} {
+	if node == nil || isNilNode(node) {
+		return nil
+	}
+	obj := func(kind string, tok token.Token, fields ...jsonField) jsonObject {
+		return append(jsonObject{{"kind", kind}, {"token", encodeToken(tok)}}, fields...)
+	}
+	switch n := node.(type) {
+	case *Program:
+		return jsonObject{{"kind", "Program"}, {"statements", encodeStatements(n.Statements)}}
+	case *Identifier:
+		return obj("Identifier", n.Token, jsonField{"value", n.Value})
+	case *LET_Statement:
+		return obj("LET_Statement", n.Token, jsonField{"name", encodeNode(n.Name)}, jsonField{"type", encodeNode(n.Type)}, jsonField{"value", encodeNode(n.Value)})
+	case *RETURN_Statement:
+		return obj("RETURN_Statement", n.Token, jsonField{"returnValue", encodeNode(n.ReturnValue)})
+	case *EXPRESSION_Statement:
+		return obj("EXPRESSION_Statement", n.Token, jsonField{"expression", encodeNode(n.Expression)})
+	case *INTEGER_Literal:
+		return obj("INTEGER_Literal", n.Token, jsonField{"value", n.Value})
+	case *PREFIX_Expression:
+		return obj("PREFIX_Expression", n.Token, jsonField{"right", encodeNode(n.Right)})
+	case *INFIX_Expression:
+		return obj("INFIX_Expression", n.Token, jsonField{"left", encodeNode(n.Left)}, jsonField{"right", encodeNode(n.Right)})
+	case *Boolean:
+		return obj("Boolean", n.Token, jsonField{"value", n.Value})
+	case *IF_Expression:
+		return obj("IF_Expression", n.Token,
+			jsonField{"condition", encodeNode(n.Condition)},
+			jsonField{"consequence", encodeNode(n.Consequence)},
+			jsonField{"alternative", encodeNode(n.Alternative)})
+	case *BlockStatement:
+		return obj("BlockStatement", n.Token, jsonField{"statements", encodeStatements(n.Statemens)})
+	case *ARRAY_Pattern:
+		elements := []interface{}{}
+		for _, el := range n.Elements {
+			elements = append(elements, encodeNode(el))
+		}
+		return obj("ARRAY_Pattern", n.Token, jsonField{"elements", elements}, jsonField{"rest", encodeNode(n.Rest)})
+	case *HASH_Pattern:
+		entries := []interface{}{}
+		for _, en := range n.Entries {
+			entries = append(entries, encodeNode(en))
+		}
+		return obj("HASH_Pattern", n.Token, jsonField{"entries", entries})
+	case *HASH_PatternEntry:
+		// the value of the shorthand `{name}` is left out, it is the key itself
+		if n.Value == Pattern(n.Key) {
+			return obj("HASH_PatternEntry", n.Token, jsonField{"key", encodeNode(n.Key)})
+		}
+		return obj("HASH_PatternEntry", n.Token, jsonField{"key", encodeNode(n.Key)}, jsonField{"value", encodeNode(n.Value)})
+	case *WILDCARD_Pattern:
+		return obj("WILDCARD_Pattern", n.Token)
+	case *LITERAL_Pattern:
+		return obj("LITERAL_Pattern", n.Token, jsonField{"value", encodeNode(n.Value)})
+	case *Parameter:
+		return obj("Parameter", n.Token,
+			jsonField{"name", encodeNode(n.Name)},
+			jsonField{"type", encodeNode(n.Type)},
+			jsonField{"default", encodeNode(n.Default)},
+			jsonField{"variadic", n.Variadic})
+	case *FunctionLiteral:
+		params := []interface{}{}
+		for _, p := range n.Parameters {
+			params = append(params, encodeNode(p))
+		}
+		return obj("FunctionLiteral", n.Token, jsonField{"parameters", params}, jsonField{"returnType", encodeNode(n.ReturnType)}, jsonField{"body", encodeNode(n.Body)})
+	case *MacroLiteral:
+		params := []interface{}{}
+		for _, p := range n.Parameters {
+			params = append(params, encodeNode(p))
+		}
+		return obj("MacroLiteral", n.Token, jsonField{"parameters", params}, jsonField{"body", encodeNode(n.Body)})
+	case *CALL_Expression:
+		return obj("CALL_Expression", n.Token, jsonField{"function", encodeNode(n.Function)}, jsonField{"arguments", encodeExpressions(n.Arguments)})
+	case *KEYWORD_Argument:
+		return obj("KEYWORD_Argument", n.Token, jsonField{"name", encodeNode(n.Name)}, jsonField{"value", encodeNode(n.Value)})
+	case *MATCH_Expression:
+		arms := []interface{}{}
+		for _, arm := range n.Arms {
+			arms = append(arms, encodeNode(arm))
+		}
+		return obj("MATCH_Expression", n.Token, jsonField{"subject", encodeNode(n.Subject)}, jsonField{"arms", arms})
+	case *MATCH_Arm:
+		return obj("MATCH_Arm", n.Token,
+			jsonField{"pattern", encodeNode(n.Pattern)},
+			jsonField{"guard", encodeNode(n.Guard)},
+			jsonField{"body", encodeNode(n.Body)})
+	case *TYPE_Name:
+		return obj("TYPE_Name", n.Token, jsonField{"value", n.Value})
+	case *ARRAY_Type:
+		return obj("ARRAY_Type", n.Token, jsonField{"element", encodeNode(n.Element)})
+	case *HASH_Type:
+		return obj("HASH_Type", n.Token, jsonField{"key", encodeNode(n.Key)}, jsonField{"value", encodeNode(n.Value)})
+	case *FUNCTION_Type:
+		params := []interface{}{}
+		for _, p := range n.Parameters {
+			params = append(params, encodeNode(p))
+		}
+		return obj("FUNCTION_Type", n.Token, jsonField{"parameters", params}, jsonField{"return", encodeNode(n.Return)})
+	}
+	panic(fmt.Sprintf("ast.EncodeJSON: unexpected node type %T", node))
+}
+
+func encodeStatements(list []Statement) []interface{} {
+	result := []interface{}{}
+	for _, stmt := range list {
+		result = append(result, encodeNode(stmt))
+	}
+	return result
+}
+
+func encodeExpressions(list []Expression) []interface{} {
+	result := []interface{}{}
+	for _, expr := range list {
+		result = append(result, encodeNode(expr))
+	}
+	return result
+}
+
+// Helper function that reports whether node is a typed nil, as left behind by a failed parse
+func isNilNode(node Node) bool {
+	v := reflect.ValueOf(node)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+/** Decoding **/
+
+// A jsonDecoder reads the fields of one encoded node and remembers the first error
+type jsonDecoder struct {
+	fields map[string]json.RawMessage
+	err    error
+}
+
+func (d *jsonDecoder) fail(err error) {
+	if d.err == nil {
+		d.err = err
+	}
+}
+
+func (d *jsonDecoder) value(key string, v interface{}) {
+	raw, ok := d.fields[key]
+	if !ok {
+		d.fail(fmt.Errorf("missing field %q", key))
+		return
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		d.fail(fmt.Errorf("field %q: %v", key, err))
+	}
+}
+
+func (d *jsonDecoder) token() token.Token {
+	var tok jsonToken
+	d.value("token", &tok)
+	return token.Token{Type: tok.Type, Literal: tok.Literal, Pos: token.Position{Offset: tok.Pos.Offset, Line: tok.Pos.Line, Column: tok.Pos.Column}}
+}
+
+// null tells whether the field under key holds null, which only optional fields may
+func (d *jsonDecoder) null(key string) bool {
+	raw, ok := d.fields[key]
+	return ok && string(bytes.TrimSpace(raw)) == "null"
+}
+
+// node decodes a child; a null field gives nil, which kind then rejects
+func (d *jsonDecoder) node(key string) Node {
+	raw, ok := d.fields[key]
+	if !ok {
+		d.fail(fmt.Errorf("missing field %q", key))
+		return nil
+	}
+	node, err := decodeNode(raw)
+	if err != nil {
+		d.fail(fmt.Errorf("%s: %v", key, err))
+	}
+	return node
+}
+
+func (d *jsonDecoder) list(key string) []Node {
+	var raws []json.RawMessage
+	d.value(key, &raws)
+	nodes := []Node{}
+	for i, raw := range raws {
+		node, err := decodeNode(raw)
+		if err != nil {
+			d.fail(fmt.Errorf("%s[%d]: %v", key, i, err))
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// kind fails unless the child decoded from key is what its field holds, e.g. not a Program where
+// an expression belongs or null where a child is required. ok is the result of the type assertion.
+func (d *jsonDecoder) kind(key string, node Node, ok bool, what string) {
+	switch {
+	case node == nil:
+		d.fail(fmt.Errorf("%s: null is not %s", key, what))
+	case !ok:
+		d.fail(fmt.Errorf("%s: %s is not %s", key, reflect.TypeOf(node).Elem().Name(), what))
+	}
+}
+
+func (d *jsonDecoder) expression(key string) Expression {
+	node := d.node(key)
+	e, ok := node.(Expression)
+	d.kind(key, node, ok, "an expression")
+	return e
+}
+
+func (d *jsonDecoder) pattern(key string) Pattern {
+	node := d.node(key)
+	p, ok := node.(Pattern)
+	d.kind(key, node, ok, "a pattern")
+	return p
+}
+
+func (d *jsonDecoder) typeExpr(key string) TypeExpr {
+	node := d.node(key)
+	t, ok := node.(TypeExpr)
+	d.kind(key, node, ok, "a type")
+	return t
+}
+
+func (d *jsonDecoder) identifier(key string) *Identifier {
+	node := d.node(key)
+	id, ok := node.(*Identifier)
+	d.kind(key, node, ok, "an Identifier")
+	return id
+}
+
+func (d *jsonDecoder) block(key string) *BlockStatement {
+	node := d.node(key)
+	b, ok := node.(*BlockStatement)
+	d.kind(key, node, ok, "a BlockStatement")
+	return b
+}
+
+/** Optional children, which are null when they are left out of the source **/
+
+func (d *jsonDecoder) optionalExpression(key string) Expression {
+	if d.null(key) {
+		return nil
+	}
+	return d.expression(key)
+}
+
+func (d *jsonDecoder) optionalIdentifier(key string) *Identifier {
+	if d.null(key) {
+		return nil
+	}
+	return d.identifier(key)
+}
+
+func (d *jsonDecoder) optionalTypeExpr(key string) TypeExpr {
+	if d.null(key) {
+		return nil
+	}
+	return d.typeExpr(key)
+}
+
+func (d *jsonDecoder) optionalBlock(key string) *BlockStatement {
+	if d.null(key) {
+		return nil
+	}
+	return d.block(key)
+}
+
+func (d *jsonDecoder) statements(key string) []Statement {
+	list := []Statement{}
+	for i, node := range d.list(key) {
+		stmt, ok := node.(Statement)
+		d.kind(fmt.Sprintf("%s[%d]", key, i), node, ok, "a statement")
+		list = append(list, stmt)
+	}
+	return list
+}
+
+func (d *jsonDecoder) expressions(key string) []Expression {
+	list := []Expression{}
+	for i, node := range d.list(key) {
+		expr, ok := node.(Expression)
+		d.kind(fmt.Sprintf("%s[%d]", key, i), node, ok, "an expression")
+		list = append(list, expr)
+	}
+	return list
+}
+
+func decodeNode(data []byte) (Node, error) {
+	if string(bytes.TrimSpace(data)) == "null" {
+		return nil, nil
+	}
+	d := &jsonDecoder{}
+	if err := json.Unmarshal(data, &d.fields); err != nil {
+		return nil, err
+	}
+	var kind string
+	d.value("kind", &kind)
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	var node Node
+	switch kind {
+	case "Program":
+		node = &Program{Statements: d.statements("statements")}
+	case "Identifier":
+		n := &Identifier{Token: d.token()}
+		d.value("value", &n.Value)
+		node = n
+	case "LET_Statement":
+		node = &LET_Statement{Token: d.token(), Name: d.pattern("name"), Type: d.optionalTypeExpr("type"), Value: d.expression("value")}
+	case "RETURN_Statement":
+		node = &RETURN_Statement{Token: d.token(), ReturnValue: d.expression("returnValue")}
+	case "EXPRESSION_Statement":
+		node = &EXPRESSION_Statement{Token: d.token(), Expression: d.expression("expression")}
+	case "INTEGER_Literal":
+		n := &INTEGER_Literal{Token: d.token()}
+		d.value("value", &n.Value)
+		node = n
+	case "PREFIX_Expression":
+		node = &PREFIX_Expression{Token: d.token(), Right: d.expression("right")}
+	case "INFIX_Expression":
+		node = &INFIX_Expression{Token: d.token(), Left: d.expression("left"), Right: d.expression("right")}
+	case "Boolean":
+		n := &Boolean{Token: d.token()}
+		d.value("value", &n.Value)
+		node = n
+	case "IF_Expression":
+		node = &IF_Expression{
+			Token:       d.token(),
+			Condition:   d.expression("condition"),
+			Consequence: d.block("consequence"),
+			Alternative: d.optionalBlock("alternative"),
+		}
+	case "BlockStatement":
+		node = &BlockStatement{Token: d.token(), Statemens: d.statements("statements")}
+	case "ARRAY_Pattern":
+		n := &ARRAY_Pattern{Token: d.token(), Elements: []Pattern{}, Rest: d.optionalIdentifier("rest")}
+		for i, el := range d.list("elements") {
+			p, ok := el.(Pattern)
+			d.kind(fmt.Sprintf("elements[%d]", i), el, ok, "a pattern")
+			n.Elements = append(n.Elements, p)
+		}
+		node = n
+	case "HASH_Pattern":
+		n := &HASH_Pattern{Token: d.token(), Entries: []*HASH_PatternEntry{}}
+		for i, en := range d.list("entries") {
+			entry, ok := en.(*HASH_PatternEntry)
+			d.kind(fmt.Sprintf("entries[%d]", i), en, ok, "a HASH_PatternEntry")
+			n.Entries = append(n.Entries, entry)
+		}
+		node = n
+	case "HASH_PatternEntry":
+		n := &HASH_PatternEntry{Token: d.token(), Key: d.identifier("key")}
+		n.Value = n.Key
+		if _, ok := d.fields["value"]; ok {
+			n.Value = d.pattern("value")
+		}
+		node = n
+	case "WILDCARD_Pattern":
+		node = &WILDCARD_Pattern{Token: d.token()}
+	case "LITERAL_Pattern":
+		node = &LITERAL_Pattern{Token: d.token(), Value: d.expression("value")}
+	case "Parameter":
+		n := &Parameter{Token: d.token(), Name: d.pattern("name"), Type: d.optionalTypeExpr("type"), Default: d.optionalExpression("default")}
+		d.value("variadic", &n.Variadic)
+		node = n
+	case "FunctionLiteral":
+		n := &FunctionLiteral{Token: d.token(), Parameters: []*Parameter{}, ReturnType: d.optionalTypeExpr("returnType"), Body: d.block("body")}
+		for i, p := range d.list("parameters") {
+			param, ok := p.(*Parameter)
+			d.kind(fmt.Sprintf("parameters[%d]", i), p, ok, "a Parameter")
+			n.Parameters = append(n.Parameters, param)
+		}
+		node = n
+	case "MacroLiteral":
+		n := &MacroLiteral{Token: d.token(), Parameters: []*Identifier{}, Body: d.block("body")}
+		for _, p := range d.list("parameters") {
+			param, _ := p.(*Identifier)
+			n.Parameters = append(n.Parameters, param)
+		}
+		node = n
+	case "CALL_Expression":
+		node = &CALL_Expression{Token: d.token(), Function: d.expression("function"), Arguments: d.expressions("arguments")}
+	case "KEYWORD_Argument":
+		node = &KEYWORD_Argument{Token: d.token(), Name: d.identifier("name"), Value: d.expression("value")}
+	case "MATCH_Expression":
+		n := &MATCH_Expression{Token: d.token(), Subject: d.expression("subject"), Arms: []*MATCH_Arm{}}
+		for i, a := range d.list("arms") {
+			arm, ok := a.(*MATCH_Arm)
+			d.kind(fmt.Sprintf("arms[%d]", i), a, ok, "a MATCH_Arm")
+			n.Arms = append(n.Arms, arm)
+		}
+		node = n
+	case "MATCH_Arm":
+		node = &MATCH_Arm{Token: d.token(), Pattern: d.pattern("pattern"), Guard: d.optionalExpression("guard"), Body: d.expression("body")}
+	case "TYPE_Name":
+		n := &TYPE_Name{Token: d.token()}
+		d.value("value", &n.Value)
+		node = n
+	case "ARRAY_Type":
+		node = &ARRAY_Type{Token: d.token(), Element: d.typeExpr("element")}
+	case "HASH_Type":
+		node = &HASH_Type{Token: d.token(), Key: d.typeExpr("key"), Value: d.typeExpr("value")}
+	case "FUNCTION_Type":
+		n := &FUNCTION_Type{Token: d.token(), Parameters: []TypeExpr{}, Return: d.typeExpr("return")}
+		for i, p := range d.list("parameters") {
+			t, ok := p.(TypeExpr)
+			d.kind(fmt.Sprintf("parameters[%d]", i), p, ok, "a type")
+			n.Parameters = append(n.Parameters, t)
+		}
+		node = n
+	default:
+		return nil, fmt.Errorf("unknown node kind %q", kind)
+	}
+	if d.err != nil {
+		return nil, fmt.Errorf("%s: %v", kind, d.err)
+	}
+	return node, nil
+}
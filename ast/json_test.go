@@ -0,0 +1,240 @@
+package ast_test
+
+import (
+	"bytes"
+	"monkey/ast"
+	"strings"
+	"testing"
+)
+
+var jsonSources = []string{
+	"let x = 5 * (2 + -3); return x;",
+	"let f = fn(a, [b, ...c], {d, e: [g]}, h = 1, ...rest) { if (a > b) { a } else { !h } };",
+	"f(1, h: 2) |> g; let add = (x, y) => x + y;",
+	"match (x) { 0 => true, -1 => false, [a, _] if a == 1 => false, {k} => k, [...r] => r };",
+	"if (true) { 1 }",
+	"let [] = xs; let {} = ys;",
+	"let f: fn(int, [bool]): {string: int} = fn(a: int = 1, ...r: [int]): int { a };",
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	for _, src := range jsonSources {
+		program := parse(t, src)
+		data, err := ast.EncodeJSON(program)
+		if err != nil {
+			t.Fatalf("encoding %q: %v", src, err)
+		}
+		decoded, err := ast.DecodeJSON(data)
+		if err != nil {
+			t.Fatalf("decoding %q: %v", src, err)
+		}
+		if decoded.Node_String() != program.Node_String() {
+			t.Errorf("%q: decoded tree prints as %s", src, decoded.Node_String())
+		}
+		// encoding the decoded tree gives back the same bytes, tokens and positions included
+		again, err := ast.EncodeJSON(decoded)
+		if err != nil {
+			t.Fatalf("encoding the decoded %q: %v", src, err)
+		}
+		if !bytes.Equal(again, data) {
+			t.Errorf("%q: re-encoded JSON differs\n got %s\nwant %s", src, again, data)
+		}
+	}
+}
+
+func TestJSONShape(t *testing.T) {
+	data, err := ast.EncodeJSON(parse(t, "x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"kind":"Program","statements":[{"kind":"EXPRESSION_Statement","token":{"type":"IDENTIFIER","literal":"x","pos":{"offset":0,"line":1,"column":1}},` +
+		`"expression":{"kind":"Identifier","token":{"type":"IDENTIFIER","literal":"x","pos":{"offset":0,"line":1,"column":1}},"value":"x"}}]}`
+	if string(data) != want {
+		t.Errorf("got  %s\nwant %s", data, want)
+	}
+}
+
+// Helper function that wraps the JSON of an expression in a program of one expression statement
+func jsonProgram(expr string) string {
+	return `{"kind": "Program", "statements": [{"kind": "EXPRESSION_Statement", "token": {}, "expression": ` + expr + `}]}`
+}
+
+// Helper function that wraps the JSON of a type in a program of one annotated let statement
+func jsonLet(typ string) string {
+	return `{"kind": "Program", "statements": [{"kind": "LET_Statement", "token": {}, "name": ` + jsonX + `, "type": ` + typ + `, "value": ` + jsonOne + `}]}`
+}
+
+const (
+	jsonInt   = `{"kind": "TYPE_Name", "token": {}, "value": "int"}`
+	jsonOne   = `{"kind": "INTEGER_Literal", "token": {}, "value": 1}`
+	jsonX     = `{"kind": "Identifier", "token": {}, "value": "x"}`
+	jsonBlock = `{"kind": "BlockStatement", "token": {}, "statements": []}`
+)
+
+func TestJSONWrongKind(t *testing.T) {
+	tests := []struct {
+		doc  string
+		want string
+	}{
+		{
+			jsonProgram(`{"kind": "INFIX_Expression", "token": {}, "left": ` + jsonOne + `, "right": {"kind": "Program", "statements": []}}`),
+			"right: Program is not an expression",
+		},
+		{
+			`{"kind": "Program", "statements": [` + jsonOne + `]}`,
+			"statements[0]: INTEGER_Literal is not a statement",
+		},
+		{
+			`{"kind": "Program", "statements": [{"kind": "LET_Statement", "token": {}, "value": ` + jsonOne + `,
+				"name": {"kind": "Boolean", "token": {}, "value": true}}]}`,
+			"name: Boolean is not a pattern",
+		},
+		{
+			jsonProgram(`{"kind": "IF_Expression", "token": {}, "condition": ` + jsonOne + `, "consequence": ` + jsonOne + `, "alternative": null}`),
+			"consequence: INTEGER_Literal is not a BlockStatement",
+		},
+		{jsonLet(jsonX), "type: Identifier is not a type"},
+		{
+			jsonLet(`{"kind": "ARRAY_Type", "token": {}, "element": ` + jsonOne + `}`),
+			"element: INTEGER_Literal is not a type",
+		},
+		{jsonProgram(`{"kind": "Nope", "token": {}}`), `unknown node kind "Nope"`},
+		{jsonProgram(`{"kind": "Identifier", "token": {}}`), `missing field "value"`},
+	}
+	for _, tt := range tests {
+		_, err := ast.DecodeJSON([]byte(tt.doc))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("got error %v, want one containing %q", err, tt.want)
+		}
+	}
+}
+
+func TestJSONNullChildren(t *testing.T) {
+	tests := []struct {
+		doc  string
+		want string
+	}{
+		{"null", "null is not a node"},
+		{
+			jsonProgram(`{"kind": "INFIX_Expression", "token": {}, "left": null, "right": ` + jsonOne + `}`),
+			"left: null is not an expression",
+		},
+		{
+			jsonProgram(`{"kind": "PREFIX_Expression", "token": {}, "right": null}`),
+			"right: null is not an expression",
+		},
+		{
+			jsonProgram(`{"kind": "IF_Expression", "token": {}, "condition": null, "consequence": ` + jsonBlock + `, "alternative": null}`),
+			"condition: null is not an expression",
+		},
+		{
+			jsonProgram(`{"kind": "IF_Expression", "token": {}, "condition": ` + jsonOne + `, "consequence": null, "alternative": null}`),
+			"consequence: null is not a BlockStatement",
+		},
+		{
+			`{"kind": "Program", "statements": [{"kind": "LET_Statement", "token": {}, "name": null, "value": ` + jsonOne + `}]}`,
+			"name: null is not a pattern",
+		},
+		{
+			`{"kind": "Program", "statements": [{"kind": "LET_Statement", "token": {}, "name": ` + jsonX + `, "type": null, "value": null}]}`,
+			"value: null is not an expression",
+		},
+		{
+			`{"kind": "Program", "statements": [{"kind": "RETURN_Statement", "token": {}, "returnValue": null}]}`,
+			"returnValue: null is not an expression",
+		},
+		{`{"kind": "Program", "statements": [null]}`, "statements[0]: null is not a statement"},
+		{
+			jsonProgram(`{"kind": "CALL_Expression", "token": {}, "function": ` + jsonX + `, "arguments": [` + jsonOne + `, null]}`),
+			"arguments[1]: null is not an expression",
+		},
+		{
+			jsonProgram(`{"kind": "CALL_Expression", "token": {}, "function": null, "arguments": []}`),
+			"function: null is not an expression",
+		},
+		{
+			jsonProgram(`{"kind": "FunctionLiteral", "token": {}, "returnType": null, "parameters": [null], "body": ` + jsonBlock + `}`),
+			"parameters[0]: null is not a Parameter",
+		},
+		{
+			jsonProgram(`{"kind": "FunctionLiteral", "token": {}, "returnType": null, "parameters": [], "body": null}`),
+			"body: null is not a BlockStatement",
+		},
+		{
+			jsonProgram(`{"kind": "MATCH_Expression", "token": {}, "subject": ` + jsonX + `, "arms": [null]}`),
+			"arms[0]: null is not a MATCH_Arm",
+		},
+		{
+			jsonProgram(`{"kind": "MATCH_Expression", "token": {}, "subject": ` + jsonX + `, "arms": [
+				{"kind": "MATCH_Arm", "token": {}, "pattern": null, "guard": null, "body": ` + jsonOne + `}]}`),
+			"pattern: null is not a pattern",
+		},
+		{
+			jsonProgram(`{"kind": "MATCH_Expression", "token": {}, "subject": ` + jsonX + `, "arms": [
+				{"kind": "MATCH_Arm", "token": {}, "pattern": ` + jsonX + `, "guard": null, "body": null}]}`),
+			"body: null is not an expression",
+		},
+		{
+			jsonProgram(`{"kind": "KEYWORD_Argument", "token": {}, "name": null, "value": ` + jsonOne + `}`),
+			"name: null is not an Identifier",
+		},
+		{
+			jsonProgram(`{"kind": "FunctionLiteral", "token": {}, "returnType": null, "body": ` + jsonBlock + `, "parameters": [
+				{"kind": "Parameter", "token": {}, "type": null, "name": {"kind": "ARRAY_Pattern", "token": {}, "elements": [null], "rest": null},
+				 "default": null, "variadic": false}]}`),
+			"elements[0]: null is not a pattern",
+		},
+		{
+			jsonProgram(`{"kind": "FunctionLiteral", "token": {}, "returnType": null, "body": ` + jsonBlock + `, "parameters": [
+				{"kind": "Parameter", "token": {}, "type": null, "name": {"kind": "HASH_Pattern", "token": {}, "entries": [null]},
+				 "default": null, "variadic": false}]}`),
+			"entries[0]: null is not a HASH_PatternEntry",
+		},
+		{
+			jsonProgram(`{"kind": "MATCH_Expression", "token": {}, "subject": ` + jsonX + `, "arms": [
+				{"kind": "MATCH_Arm", "token": {}, "pattern": {"kind": "LITERAL_Pattern", "token": {}, "value": null}, "guard": null, "body": ` + jsonOne + `}]}`),
+			"value: null is not an expression",
+		},
+		{
+			jsonLet(`{"kind": "ARRAY_Type", "token": {}, "element": null}`),
+			"element: null is not a type",
+		},
+		{
+			jsonLet(`{"kind": "HASH_Type", "token": {}, "key": ` + jsonInt + `, "value": null}`),
+			"value: null is not a type",
+		},
+		{
+			jsonLet(`{"kind": "FUNCTION_Type", "token": {}, "parameters": [null], "return": ` + jsonInt + `}`),
+			"parameters[0]: null is not a type",
+		},
+		{
+			jsonLet(`{"kind": "FUNCTION_Type", "token": {}, "parameters": [], "return": null}`),
+			"return: null is not a type",
+		},
+	}
+	for _, tt := range tests {
+		_, err := ast.DecodeJSON([]byte(tt.doc))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("got error %v, want one containing %q", err, tt.want)
+		}
+	}
+}
+
+func TestJSONOptionalChildren(t *testing.T) {
+	// alternative, rest, default, guard and the type annotations may be null
+	docs := []string{
+		jsonLet("null"),
+		jsonLet(`{"kind": "FUNCTION_Type", "token": {}, "parameters": [], "return": ` + jsonInt + `}`),
+		jsonProgram(`{"kind": "IF_Expression", "token": {}, "condition": ` + jsonOne + `, "consequence": ` + jsonBlock + `, "alternative": null}`),
+		jsonProgram(`{"kind": "FunctionLiteral", "token": {}, "returnType": null, "body": ` + jsonBlock + `, "parameters": [
+			{"kind": "Parameter", "token": {}, "type": null, "name": {"kind": "ARRAY_Pattern", "token": {}, "elements": [], "rest": null},
+			 "default": null, "variadic": false}]}`),
+		jsonProgram(`{"kind": "MATCH_Expression", "token": {}, "subject": ` + jsonX + `, "arms": [
+			{"kind": "MATCH_Arm", "token": {}, "pattern": ` + jsonX + `, "guard": null, "body": ` + jsonOne + `}]}`),
+	}
+	for _, doc := range docs {
+		if _, err := ast.DecodeJSON([]byte(doc)); err != nil {
+			t.Errorf("unexpected error %v", err)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package ast
+
+// A ModifierFunc returns the node that replaces the node it is given
+type ModifierFunc func(Node) Node
+
+// Modify rewrites an AST in post-order: the children of node are modified first and stored back
+// into their fields, then node itself is passed to modifier and the result is returned.
+// A replacement of the wrong type for its field, e.g. a statement where an expression belongs,
+// leaves the field nil.
+func Modify(node Node, modifier ModifierFunc) Node {
+	switch node := node.(type) {
+	case *Program:
+		modifyStatements(node.Statements, modifier)
+
+	case *LET_Statement:
+		node.Name, _ = Modify(node.Name, modifier).(Pattern)
+		if node.Type != nil {
+			node.Type, _ = Modify(node.Type, modifier).(TypeExpr)
+		}
+		if node.Value != nil {
+			node.Value, _ = Modify(node.Value, modifier).(Expression)
+		}
+
+	case *RETURN_Statement:
+		if node.ReturnValue != nil {
+			node.ReturnValue, _ = Modify(node.ReturnValue, modifier).(Expression)
+		}
+
+	case *EXPRESSION_Statement:
+		if node.Expression != nil {
+			node.Expression, _ = Modify(node.Expression, modifier).(Expression)
+		}
+
+	case *BlockStatement:
+		modifyStatements(node.Statemens, modifier)
+
+	case *PREFIX_Expression:
+		node.Right, _ = Modify(node.Right, modifier).(Expression)
+
+	case *INFIX_Expression:
+		node.Left, _ = Modify(node.Left, modifier).(Expression)
+		node.Right, _ = Modify(node.Right, modifier).(Expression)
+
+	case *IF_Expression:
+		node.Condition, _ = Modify(node.Condition, modifier).(Expression)
+		node.Consequence, _ = Modify(node.Consequence, modifier).(*BlockStatement)
+		if node.Alternative != nil {
+			node.Alternative, _ = Modify(node.Alternative, modifier).(*BlockStatement)
+		}
+
+	case *ARRAY_Pattern:
+		for i, el := range node.Elements {
+			node.Elements[i], _ = Modify(el, modifier).(Pattern)
+		}
+		if node.Rest != nil {
+			node.Rest, _ = Modify(node.Rest, modifier).(*Identifier)
+		}
+
+	case *HASH_Pattern:
+		for i, en := range node.Entries {
+			node.Entries[i], _ = Modify(en, modifier).(*HASH_PatternEntry)
+		}
+
+	case *HASH_PatternEntry:
+		// the shorthand `{name}` shares one Identifier between key and value
+		shorthand := node.Value == Pattern(node.Key)
+		node.Key, _ = Modify(node.Key, modifier).(*Identifier)
+		if shorthand {
+			node.Value = node.Key
+		} else {
+			node.Value, _ = Modify(node.Value, modifier).(Pattern)
+		}
+
+	case *LITERAL_Pattern:
+		node.Value, _ = Modify(node.Value, modifier).(Expression)
+
+	case *Parameter:
+		node.Name, _ = Modify(node.Name, modifier).(Pattern)
+		if node.Type != nil {
+			node.Type, _ = Modify(node.Type, modifier).(TypeExpr)
+		}
+		if node.Default != nil {
+			node.Default, _ = Modify(node.Default, modifier).(Expression)
+		}
+
+	case *FunctionLiteral:
+		for i, p := range node.Parameters {
+			node.Parameters[i], _ = Modify(p, modifier).(*Parameter)
+		}
+		if node.ReturnType != nil {
+			node.ReturnType, _ = Modify(node.ReturnType, modifier).(TypeExpr)
+		}
+		node.Body, _ = Modify(node.Body, modifier).(*BlockStatement)
+
+	case *MacroLiteral:
+		for i, p := range node.Parameters {
+			node.Parameters[i], _ = Modify(p, modifier).(*Identifier)
+		}
+		node.Body, _ = Modify(node.Body, modifier).(*BlockStatement)
+
+	case *CALL_Expression:
+		node.Function, _ = Modify(node.Function, modifier).(Expression)
+		modifyExpressions(node.Arguments, modifier)
+
+	case *KEYWORD_Argument:
+		node.Name, _ = Modify(node.Name, modifier).(*Identifier)
+		node.Value, _ = Modify(node.Value, modifier).(Expression)
+
+	case *MATCH_Expression:
+		node.Subject, _ = Modify(node.Subject, modifier).(Expression)
+		for i, arm := range node.Arms {
+			node.Arms[i], _ = Modify(arm, modifier).(*MATCH_Arm)
+		}
+
+	case *MATCH_Arm:
+		node.Pattern, _ = Modify(node.Pattern, modifier).(Pattern)
+		if node.Guard != nil {
+			node.Guard, _ = Modify(node.Guard, modifier).(Expression)
+		}
+		node.Body, _ = Modify(node.Body, modifier).(Expression)
+
+	case *ARRAY_Type:
+		node.Element, _ = Modify(node.Element, modifier).(TypeExpr)
+
+	case *HASH_Type:
+		node.Key, _ = Modify(node.Key, modifier).(TypeExpr)
+		node.Value, _ = Modify(node.Value, modifier).(TypeExpr)
+
+	case *FUNCTION_Type:
+		for i, p := range node.Parameters {
+			node.Parameters[i], _ = Modify(p, modifier).(TypeExpr)
+		}
+		node.Return, _ = Modify(node.Return, modifier).(TypeExpr)
+	}
+
+	return modifier(node)
+}
+
+func modifyStatements(list []Statement, modifier ModifierFunc) {
+	for i, stmt := range list {
+		list[i], _ = Modify(stmt, modifier).(Statement)
+	}
+}
+
+func modifyExpressions(list []Expression, modifier ModifierFunc) {
+	for i, expr := range list {
+		list[i], _ = Modify(expr, modifier).(Expression)
+	}
+}
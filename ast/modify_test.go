@@ -0,0 +1,101 @@
+package ast_test
+
+import (
+	"monkey/ast"
+	"monkey/token"
+	"strings"
+	"testing"
+)
+
+// turns every integer literal 1 into 2
+func turnOneIntoTwo(node ast.Node) ast.Node {
+	il, ok := node.(*ast.INTEGER_Literal)
+	if !ok || il.Value != 1 {
+		return node
+	}
+	return &ast.INTEGER_Literal{Token: token.Token{Type: token.INT, Literal: "2"}, Value: 2}
+}
+
+func TestModify(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"1", "2;"},
+		{"let x = 1;", "let x = 2;"},
+		{"return 1;", "return 2;"},
+		{"-1", "(-2);"},
+		{"1 + 1", "(2+2);"},
+		{"if (1) { 1 } else { 1 }", "if (2) { 2; } else { 2; };"},
+		{"fn(a = 1) { 1 }", "fn(a = 2) { 2; };"},
+		{"f(1, b: 1)", "f(2, b: 2);"},
+		{"match (1) { 1 if 1 => 1 }", "match (2) {2 if 2 => 2};"},
+		{"let [a, ...b] = 1;", "let [a, ...b] = 2;"},
+	}
+	for _, tt := range tests {
+		program := parse(t, tt.input)
+		modified := ast.Modify(program, turnOneIntoTwo)
+		if got := strings.TrimSuffix(modified.Node_String(), "\n"); got != tt.want {
+			t.Errorf("%q: got %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestModifyPostOrder(t *testing.T) {
+	program := parse(t, "f(a + b)")
+	order := []string{}
+	ast.Modify(program, func(node ast.Node) ast.Node {
+		switch n := node.(type) {
+		case *ast.Identifier:
+			order = append(order, n.Value)
+		case *ast.INFIX_Expression:
+			order = append(order, "+")
+		case *ast.CALL_Expression:
+			order = append(order, "call")
+		}
+		return node
+	})
+	if got := strings.Join(order, " "); got != "f a b + call" {
+		t.Errorf("modified in order %s, want f a b + call", got)
+	}
+}
+
+func TestModifyRenamesPatterns(t *testing.T) {
+	program := parse(t, "let {name, age: [years]} = p; fn(...rest) { name }")
+	ast.Modify(program, func(node ast.Node) ast.Node {
+		if id, ok := node.(*ast.Identifier); ok {
+			return &ast.Identifier{Token: id.Token, Value: strings.ToUpper(id.Value)}
+		}
+		return node
+	})
+	// Node_String prints the token, so read the renamed values through the tree
+	let := program.Statements[0].(*ast.LET_Statement)
+	entries := let.Name.(*ast.HASH_Pattern).Entries
+	if entries[0].Key != entries[0].Value.(*ast.Identifier) {
+		t.Errorf("the shorthand entry no longer shares its key and value")
+	}
+	if entries[0].Key.Value != "NAME" || entries[1].Key.Value != "AGE" {
+		t.Errorf("keys are %s and %s", entries[0].Key.Value, entries[1].Key.Value)
+	}
+	if years := entries[1].Value.(*ast.ARRAY_Pattern).Elements[0].(*ast.Identifier); years.Value != "YEARS" {
+		t.Errorf("nested pattern name is %s", years.Value)
+	}
+	fl := program.Statements[1].(*ast.EXPRESSION_Statement).Expression.(*ast.FunctionLiteral)
+	if rest := fl.Parameters[0].Name.(*ast.Identifier); rest.Value != "REST" {
+		t.Errorf("rest parameter is %s", rest.Value)
+	}
+}
+
+func TestModifyWrongType(t *testing.T) {
+	program := parse(t, "let x = 1 + y;")
+	ast.Modify(program, func(node ast.Node) ast.Node {
+		if id, ok := node.(*ast.Identifier); ok && id.Value == "y" {
+			return &ast.RETURN_Statement{}
+		}
+		return node
+	})
+	infix := program.Statements[0].(*ast.LET_Statement).Value.(*ast.INFIX_Expression)
+	if infix.Right != nil {
+		t.Errorf("a statement in an expression field gives %T, want nil", infix.Right)
+	}
+}
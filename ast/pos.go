@@ -0,0 +1,104 @@
+package ast
+
+import "monkey/token"
+
+// Pos returns where node starts in the source, which is the position of its first token. That is
+// not always the token the node holds: an INFIX_Expression holds its operator, for one.
+// It is the zero Position for nodes that were not parsed, e.g. an empty Program.
+func Pos(node Node) token.Position {
+	switch n := node.(type) {
+	case *Program:
+		if len(n.Statements) > 0 {
+			return Pos(n.Statements[0])
+		}
+		return token.Position{}
+	case *EXPRESSION_Statement:
+		if n.Expression != nil {
+			return earliest(n.Token.Pos, Pos(n.Expression))
+		}
+		return n.Token.Pos
+	case *INFIX_Expression:
+		return Pos(n.Left)
+	case *CALL_Expression:
+		// a call made with the pipe operator starts with its first argument
+		pos := Pos(n.Function)
+		if len(n.Arguments) > 0 {
+			pos = earliest(pos, Pos(n.Arguments[0]))
+		}
+		return pos
+	case *FunctionLiteral:
+		// an arrow function holds the position of its `=>`
+		if len(n.Parameters) > 0 {
+			return earliest(n.Token.Pos, n.Parameters[0].Token.Pos)
+		}
+		return n.Token.Pos
+	}
+	return tokenOf(node).Pos
+}
+
+// Helper function that returns the earlier of two positions, ignoring the zero Position of synthesized tokens
+func earliest(a, b token.Position) token.Position {
+	if a.Line == 0 || b.Line != 0 && b.Offset < a.Offset {
+		return b
+	}
+	return a
+}
+
+// Helper function that returns the token a node holds
+func tokenOf(node Node) token.Token {
+	switch n := node.(type) {
+	case *Identifier:
+		return n.Token
+	case *LET_Statement:
+		return n.Token
+	case *RETURN_Statement:
+		return n.Token
+	case *EXPRESSION_Statement:
+		return n.Token
+	case *INTEGER_Literal:
+		return n.Token
+	case *PREFIX_Expression:
+		return n.Token
+	case *INFIX_Expression:
+		return n.Token
+	case *Boolean:
+		return n.Token
+	case *IF_Expression:
+		return n.Token
+	case *BlockStatement:
+		return n.Token
+	case *ARRAY_Pattern:
+		return n.Token
+	case *HASH_Pattern:
+		return n.Token
+	case *HASH_PatternEntry:
+		return n.Token
+	case *WILDCARD_Pattern:
+		return n.Token
+	case *LITERAL_Pattern:
+		return n.Token
+	case *Parameter:
+		return n.Token
+	case *FunctionLiteral:
+		return n.Token
+	case *MacroLiteral:
+		return n.Token
+	case *CALL_Expression:
+		return n.Token
+	case *KEYWORD_Argument:
+		return n.Token
+	case *MATCH_Expression:
+		return n.Token
+	case *MATCH_Arm:
+		return n.Token
+	case *TYPE_Name:
+		return n.Token
+	case *ARRAY_Type:
+		return n.Token
+	case *HASH_Type:
+		return n.Token
+	case *FUNCTION_Type:
+		return n.Token
+	}
+	return token.Token{}
+}
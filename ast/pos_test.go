@@ -0,0 +1,41 @@
+package ast_test
+
+import (
+	"monkey/ast"
+	"monkey/token"
+	"testing"
+)
+
+func TestPos(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string // the position of the first statement
+	}{
+		{"let x = 1;", "1:1"},
+		{"  return x;", "1:3"},
+		{"\n  x", "2:3"},
+		{"a + b * c", "1:1"},
+		{"(a + b) * c", "1:1"},
+		{"-a", "1:1"},
+		{"f(x)", "1:1"},
+		{"x |> f(y)", "1:1"},
+		{"x |> f", "1:1"},
+		{"x => x", "1:1"},
+		{"(x, y) => x", "1:1"},
+		{"fn(x) { x }", "1:1"},
+		{"if (x) { y }", "1:1"},
+		{"match (x) { _ => 1 }", "1:1"},
+	}
+	for _, tt := range tests {
+		program := parse(t, tt.input)
+		if got := ast.Pos(program.Statements[0]).String(); got != tt.want {
+			t.Errorf("%q: got %s, want %s", tt.input, got, tt.want)
+		}
+		if got := ast.Pos(program).String(); got != tt.want {
+			t.Errorf("%q: program at %s, want %s", tt.input, got, tt.want)
+		}
+	}
+	if pos := ast.Pos(&ast.Program{}); pos != (token.Position{}) {
+		t.Errorf("empty program at %s, want the zero Position", pos)
+	}
+}
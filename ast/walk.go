@@ -0,0 +1,179 @@
+package ast
+
+import "fmt"
+
+// A Visitor's Visit method is invoked for each node encountered by Walk.
+// If the result visitor w is not nil, Walk visits each of the children
+// of node with the visitor w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses an AST in depth-first order: It starts by calling v.Visit(node); node must not be nil.
+// If the visitor w returned by v.Visit(node) is not nil, Walk is invoked recursively with visitor w
+// for each of the non-nil children of node, followed by a call of w.Visit(nil).
+//
+// The shorthand entry `{name}` of a HASH_Pattern uses the same Identifier as key and value;
+// it is visited once.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		walkStatements(v, n.Statements)
+
+	case *Identifier, *INTEGER_Literal, *Boolean, *WILDCARD_Pattern, *TYPE_Name:
+		// nothing to do
+
+	case *LET_Statement:
+		Walk(v, n.Name)
+		if n.Type != nil {
+			Walk(v, n.Type)
+		}
+		if n.Value != nil {
+			Walk(v, n.Value)
+		}
+
+	case *RETURN_Statement:
+		if n.ReturnValue != nil {
+			Walk(v, n.ReturnValue)
+		}
+
+	case *EXPRESSION_Statement:
+		if n.Expression != nil {
+			Walk(v, n.Expression)
+		}
+
+	case *BlockStatement:
+		walkStatements(v, n.Statemens)
+
+	case *PREFIX_Expression:
+		Walk(v, n.Right)
+
+	case *INFIX_Expression:
+		Walk(v, n.Left)
+		Walk(v, n.Right)
+
+	case *IF_Expression:
+		Walk(v, n.Condition)
+		Walk(v, n.Consequence)
+		if n.Alternative != nil {
+			Walk(v, n.Alternative)
+		}
+
+	case *ARRAY_Pattern:
+		for _, el := range n.Elements {
+			Walk(v, el)
+		}
+		if n.Rest != nil {
+			Walk(v, n.Rest)
+		}
+
+	case *HASH_Pattern:
+		for _, en := range n.Entries {
+			Walk(v, en)
+		}
+
+	case *HASH_PatternEntry:
+		Walk(v, n.Key)
+		if n.Value != Pattern(n.Key) {
+			Walk(v, n.Value)
+		}
+
+	case *LITERAL_Pattern:
+		Walk(v, n.Value)
+
+	case *Parameter:
+		Walk(v, n.Name)
+		if n.Type != nil {
+			Walk(v, n.Type)
+		}
+		if n.Default != nil {
+			Walk(v, n.Default)
+		}
+
+	case *FunctionLiteral:
+		for _, p := range n.Parameters {
+			Walk(v, p)
+		}
+		if n.ReturnType != nil {
+			Walk(v, n.ReturnType)
+		}
+		Walk(v, n.Body)
+
+	case *MacroLiteral:
+		for _, p := range n.Parameters {
+			Walk(v, p)
+		}
+		Walk(v, n.Body)
+
+	case *CALL_Expression:
+		Walk(v, n.Function)
+		walkExpressions(v, n.Arguments)
+
+	case *KEYWORD_Argument:
+		Walk(v, n.Name)
+		Walk(v, n.Value)
+
+	case *MATCH_Expression:
+		Walk(v, n.Subject)
+		for _, arm := range n.Arms {
+			Walk(v, arm)
+		}
+
+	case *MATCH_Arm:
+		Walk(v, n.Pattern)
+		if n.Guard != nil {
+			Walk(v, n.Guard)
+		}
+		Walk(v, n.Body)
+
+	case *ARRAY_Type:
+		Walk(v, n.Element)
+
+	case *HASH_Type:
+		Walk(v, n.Key)
+		Walk(v, n.Value)
+
+	case *FUNCTION_Type:
+		for _, p := range n.Parameters {
+			Walk(v, p)
+		}
+		Walk(v, n.Return)
+
+	default:
+		panic(fmt.Sprintf("ast.Walk: unexpected node type %T", n))
+	}
+
+	v.Visit(nil)
+}
+
+func walkStatements(v Visitor, list []Statement) {
+	for _, stmt := range list {
+		Walk(v, stmt)
+	}
+}
+
+func walkExpressions(v Visitor, list []Expression) {
+	for _, expr := range list {
+		Walk(v, expr)
+	}
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses an AST in depth-first order: It starts by calling f(node); node must not be nil.
+// If f returns true, Inspect invokes f recursively for each of the non-nil children of node,
+// followed by a call of f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
@@ -0,0 +1,151 @@
+package ast_test
+
+import (
+	"fmt"
+	"monkey/ast"
+	"monkey/lexer"
+	"monkey/parser"
+	"strings"
+	"testing"
+)
+
+// Helper function that parses input and fails the test on a parse error
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+	ps := parser.New(lexer.New(input))
+	program := ps.ParseProgram()
+	if errs := ps.Errors(); len(errs) > 0 {
+		t.Fatalf("parsing %q: %s", input, errs)
+	}
+	return program
+}
+
+// Helper function that lists the nodes Inspect visits, as type names indented by depth
+func inspectTrace(node ast.Node) []string {
+	trace := []string{}
+	depth := 0
+	ast.Inspect(node, func(n ast.Node) bool {
+		if n == nil {
+			depth--
+			return false
+		}
+		trace = append(trace, strings.Repeat(".", depth)+strings.TrimPrefix(fmt.Sprintf("%T", n), "*ast."))
+		depth++
+		return true
+	})
+	return trace
+}
+
+func TestInspectOrder(t *testing.T) {
+	program := parse(t, "let f = fn(a, [b, ...c] = d) { return f(a, k: -1); }; match (x) { {p, q: 1} if p => p, _ => 0 }")
+	want := []string{
+		"Program",
+		".LET_Statement",
+		"..Identifier",
+		"..FunctionLiteral",
+		"...Parameter",
+		"....Identifier",
+		"...Parameter",
+		"....ARRAY_Pattern",
+		".....Identifier",
+		".....Identifier",
+		"....Identifier",
+		"...BlockStatement",
+		"....RETURN_Statement",
+		".....CALL_Expression",
+		"......Identifier",
+		"......Identifier",
+		"......KEYWORD_Argument",
+		".......Identifier",
+		".......PREFIX_Expression",
+		"........INTEGER_Literal",
+		".EXPRESSION_Statement",
+		"..MATCH_Expression",
+		"...Identifier",
+		"...MATCH_Arm",
+		"....HASH_Pattern",
+		".....HASH_PatternEntry",
+		"......Identifier",
+		".....HASH_PatternEntry",
+		"......Identifier",
+		"......LITERAL_Pattern",
+		".......INTEGER_Literal",
+		"....Identifier",
+		"....Identifier",
+		"...MATCH_Arm",
+		"....WILDCARD_Pattern",
+		"....INTEGER_Literal",
+	}
+	got := inspectTrace(program)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestInspectIfAndInfix(t *testing.T) {
+	got := inspectTrace(parse(t, "if (a < true) { 1 } else { b }"))
+	want := []string{
+		"Program",
+		".EXPRESSION_Statement",
+		"..IF_Expression",
+		"...INFIX_Expression",
+		"....Identifier",
+		"....Boolean",
+		"...BlockStatement",
+		"....EXPRESSION_Statement",
+		".....INTEGER_Literal",
+		"...BlockStatement",
+		"....EXPRESSION_Statement",
+		".....Identifier",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestInspectPrune(t *testing.T) {
+	program := parse(t, "let a = 1; let f = fn(x) { let b = x; b }; a + f(2)")
+	names := []string{}
+	ast.Inspect(program, func(n ast.Node) bool {
+		if _, ok := n.(*ast.FunctionLiteral); ok {
+			return false
+		}
+		if id, ok := n.(*ast.Identifier); ok {
+			names = append(names, id.Value)
+		}
+		return true
+	})
+	if got := strings.Join(names, " "); got != "a f a f" {
+		t.Errorf("identifiers outside functions: %s, want a f a f", got)
+	}
+}
+
+// counts how often Visit is called with a node and with nil
+type counter struct {
+	nodes, nils int
+}
+
+func (c *counter) Visit(node ast.Node) ast.Visitor {
+	if node == nil {
+		c.nils++
+	} else {
+		c.nodes++
+	}
+	return c
+}
+
+func TestWalkBalanced(t *testing.T) {
+	inputs := []string{
+		"let x = 1;",
+		"xs |> map(x => x * 2)",
+		"let {a, b: [c, ...d]} = e; return a;",
+		"match (1) { -1 => 0, n if n > 1 => n }",
+	}
+	for _, input := range inputs {
+		c := &counter{}
+		ast.Walk(c, parse(t, input))
+		if c.nodes == 0 || c.nodes != c.nils {
+			t.Errorf("%q: %d nodes visited but %d closing nil visits", input, c.nodes, c.nils)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"monkey/ast"
+	"os"
+	"strings"
+)
+
+// monkey ast [-json | -dot] [file]
+func astCommand(args []string) int {
+	flags := flag.NewFlagSet("monkey ast", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the tree as JSON")
+	asDOT := flags.Bool("dot", false, "print the tree as a Graphviz DOT graph")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: monkey ast [-json | -dot] [file]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() > 1 || *asJSON && *asDOT {
+		flags.Usage()
+		return 2
+	}
+
+	program, ok := parseFile(flags.Arg(0), nil)
+	if !ok {
+		return 1
+	}
+	if *asJSON {
+		data, err := ast.EncodeJSON(program)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		var out bytes.Buffer
+		if err := json.Indent(&out, data, "", "  "); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		out.WriteString("\n")
+		out.WriteTo(os.Stdout)
+		return 0
+	}
+	if *asDOT {
+		if err := ast.WriteDOT(os.Stdout, program); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+	printTree(program)
+	return 0
+}
+
+// Helper function that prints one line per node, indented by depth
+func printTree(node ast.Node) {
+	depth := 0
+	ast.Inspect(node, func(n ast.Node) bool {
+		if n == nil {
+			depth--
+			return false
+		}
+		fmt.Printf("%s%s", strings.Repeat("  ", depth), strings.TrimPrefix(fmt.Sprintf("%T", n), "*ast."))
+		if _, isProgram := n.(*ast.Program); !isProgram && n.Token_Literal() != "" {
+			fmt.Printf(" %q", n.Token_Literal())
+		}
+		fmt.Println()
+		depth++
+		return true
+	})
+}
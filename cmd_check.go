@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"monkey/ast"
+	"monkey/diag"
+	"monkey/macro"
+	"monkey/resolver"
+	"monkey/typecheck"
+	"os"
+)
+
+// monkey check [-types] [file]
+func checkCommand(args []string) int {
+	flags := flag.NewFlagSet("monkey check", flag.ExitOnError)
+	showTypes := flags.Bool("types", false, "print the inferred type of every top-level binding")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: monkey check [-types] [file]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+
+	program, ok := parseFile(flags.Arg(0), nil)
+	if !ok {
+		return 1
+	}
+	diags := macro.Expand(program)
+	diags = append(diags, resolver.Resolve(program)...)
+	info, types := typecheck.Check(program)
+	diags = append(diags, types...)
+	diags.Sort()
+	printDiagnostics(flags.Arg(0), diags)
+	if *showTypes {
+		printTypes(program, info)
+	}
+	if diags.HasErrors() {
+		return 1
+	}
+	return 0
+}
+
+// Helper function that prints `name: type` for each name bound by a top-level let
+func printTypes(program *ast.Program, info *typecheck.Info) {
+	for _, stmt := range program.Statements {
+		if let, ok := stmt.(*ast.LET_Statement); ok && let.Name != nil {
+			for _, id := range ast.BoundIdentifiers(let.Name) {
+				fmt.Printf("%s: %s\n", id.Value, typecheck.TypeString(info.Defs[id]))
+			}
+		}
+	}
+}
+
+// Helper function that prints diagnostics on standard error, prefixed with the source name
+func printDiagnostics(name string, diags diag.List) {
+	for _, d := range diags {
+		fmt.Fprintf(os.Stderr, "%s:%s\n", sourceName(name), d)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"monkey/format"
+	"monkey/macro"
+	"os"
+)
+
+// monkey expand [file]
+func expandCommand(args []string) int {
+	flags := flag.NewFlagSet("monkey expand", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: monkey expand [file]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+
+	program, ok := parseFile(flags.Arg(0), nil)
+	if !ok {
+		return 1
+	}
+	diags := macro.Expand(program)
+	printDiagnostics(flags.Arg(0), diags)
+	if diags.HasErrors() {
+		return 1
+	}
+	fmt.Print(format.Node(program))
+	return 0
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"monkey/format"
+	"monkey/parser"
+	"os"
+	"strings"
+)
+
+// monkey fmt [-w] [-l] [-d] [files...]
+func fmtCommand(args []string) int {
+	flags := flag.NewFlagSet("monkey fmt", flag.ExitOnError)
+	write := flags.Bool("w", false, "write the result back to the file instead of standard output")
+	list := flags.Bool("l", false, "list the files whose formatting differs")
+	diff := flags.Bool("d", false, "print a diff instead of the formatted source")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: monkey fmt [-w] [-l] [-d] [files...]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if *write && flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "monkey fmt: cannot use -w with standard input")
+		return 2
+	}
+
+	names := flags.Args()
+	if len(names) == 0 {
+		names = []string{""}
+	}
+	status := 0
+	for _, name := range names {
+		if !formatFile(name, *write, *list, *diff) {
+			status = 1
+		}
+	}
+	return status
+}
+
+// Helper function that formats one file, or standard input if name is empty, as the flags ask
+func formatFile(name string, write, list, diff bool) bool {
+	src, err := readSource(name)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return false
+	}
+	out, err := format.Source(src)
+	if err != nil {
+		if errs, ok := err.(parser.ErrorList); ok {
+			for _, e := range errs {
+				fmt.Fprintf(os.Stderr, "%s:%s\n", sourceName(name), e)
+			}
+		} else {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", sourceName(name), err)
+		}
+		return false
+	}
+
+	changed := !bytes.Equal(src, out)
+	if list && changed {
+		fmt.Println(sourceName(name))
+	}
+	if write && changed {
+		info, err := os.Stat(name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return false
+		}
+		if err := os.WriteFile(name, out, info.Mode().Perm()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return false
+		}
+	}
+	if diff && changed {
+		fmt.Print(lineDiff(sourceName(name), string(src), string(out)))
+	}
+	if !list && !write && !diff {
+		os.Stdout.Write(out)
+	}
+	return true
+}
+
+// Helper function that returns a unified diff of two texts, with 3 lines of context around each change
+func lineDiff(name, before, after string) string {
+	edits := diffLines(splitLines(before), splitLines(after), nil)
+
+	const context = 3
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s (formatted)\n", name, name)
+	for start := 0; start < len(edits); {
+		if edits[start].op == ' ' {
+			start++
+			continue
+		}
+		// a hunk runs until more than 2*context unchanged lines separate it from the next change
+		end := start
+		for k := start; k < len(edits) && k-end <= 2*context; k++ {
+			if edits[k].op != ' ' {
+				end = k + 1
+			}
+		}
+		from := start - context
+		if from < 0 {
+			from = 0
+		}
+		to := end + context
+		if to > len(edits) {
+			to = len(edits)
+		}
+		// line numbers of the hunk's first line in each text
+		aLine, bLine := 1, 1
+		for _, e := range edits[:from] {
+			if e.op != '+' {
+				aLine++
+			}
+			if e.op != '-' {
+				bLine++
+			}
+		}
+		aCount, bCount := 0, 0
+		for _, e := range edits[from:to] {
+			if e.op != '+' {
+				aCount++
+			}
+			if e.op != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", aLine, aCount, bLine, bCount)
+		for _, e := range edits[from:to] {
+			out.WriteByte(e.op)
+			out.WriteString(e.line + "\n")
+		}
+		start = to
+	}
+	return out.String()
+}
+
+// One line of an edit script: op is ' ' for a line both texts share, '-' for a removed line
+// and '+' for an added one
+type edit struct {
+	op   byte
+	line string
+}
+
+// Helper function that appends the shortest edit script turning a into b to edits. It is Myers'
+// diff with the linear space refinement: the middle snake of an optimal path splits the problem
+// in two, so memory stays proportional to len(a)+len(b) however far apart the texts are.
+func diffLines(a, b []string, edits []edit) []edit {
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		edits = append(edits, edit{' ', a[0]})
+		a, b = a[1:], b[1:]
+	}
+	suffix := 0
+	for suffix < len(a) && suffix < len(b) && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	common := a[len(a)-suffix:]
+	a, b = a[:len(a)-suffix], b[:len(b)-suffix]
+
+	switch {
+	case len(a) == 0:
+		for _, line := range b {
+			edits = append(edits, edit{'+', line})
+		}
+	case len(b) == 0:
+		for _, line := range a {
+			edits = append(edits, edit{'-', line})
+		}
+	default:
+		x, y, u, v := middleSnake(a, b)
+		edits = diffLines(a[:x], b[:y], edits)
+		for _, line := range a[x:u] {
+			edits = append(edits, edit{' ', line})
+		}
+		edits = diffLines(a[u:], b[v:], edits)
+	}
+	for _, line := range common {
+		edits = append(edits, edit{' ', line})
+	}
+	return edits
+}
+
+// Helper function that finds the middle snake of a shortest edit script turning a into b: the run
+// of shared lines a[x:u] == b[y:v] where the paths searched from both ends of the texts meet
+func middleSnake(a, b []string) (x, y, u, v int) {
+	n, m := len(a), len(b)
+	delta := n - m
+	odd := delta%2 != 0
+	max := (n + m + 1) / 2
+	offset := max + 1
+	// forward[offset+k] is the furthest x reached on diagonal k = x-y from the start,
+	// backward[offset+c] the furthest reached on diagonal c from the end, counted from the end
+	forward := make([]int, 2*max+3)
+	backward := make([]int, 2*max+3)
+	for d := 0; d <= max; d++ {
+		for k := -d; k <= d; k += 2 {
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y = x - k
+			u, v = x, y
+			for u < n && v < m && a[u] == b[v] {
+				u++
+				v++
+			}
+			forward[offset+k] = u
+			if c := delta - k; odd && c >= -(d-1) && c <= d-1 && u+backward[offset+c] >= n {
+				return x, y, u, v
+			}
+		}
+		for c := -d; c <= d; c += 2 {
+			var rx int
+			if c == -d || (c != d && backward[offset+c-1] < backward[offset+c+1]) {
+				rx = backward[offset+c+1]
+			} else {
+				rx = backward[offset+c-1] + 1
+			}
+			ry := rx - c
+			ru, rv := rx, ry
+			for ru < n && rv < m && a[n-ru-1] == b[m-rv-1] {
+				ru++
+				rv++
+			}
+			backward[offset+c] = ru
+			if k := delta - c; !odd && k >= -d && k <= d && ru+forward[offset+k] >= n {
+				return n - ru, m - rv, n - rx, m - ry
+			}
+		}
+	}
+	panic("middleSnake: the paths never met")
+}
+
+// Helper function that splits text into lines without their line endings
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestLineDiff(t *testing.T) {
+	before := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\n"
+	after := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+	want := `--- x.mk
++++ x.mk (formatted)
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -10,3 +10,4 @@
+ j
+ k
+ l
++m
+`
+	if got := lineDiff("x.mk", before, after); got != want {
+		t.Errorf("lineDiff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int // length of the shortest edit script
+	}{
+		{"", "", 0},
+		{"abc", "abc", 0},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"abcabba", "cbabac", 5},
+		{"abcdef", "fedcba", 10},
+		{"xaxbxc", "ayb", 5},
+	}
+	for _, tt := range tests {
+		a, b := strings.Split(tt.a, ""), strings.Split(tt.b, "")
+		edits := diffLines(a, b, nil)
+		if got := applyEdits(t, a, edits); strings.Join(got, "") != tt.b {
+			t.Errorf("diffLines(%q, %q) builds %q", tt.a, tt.b, strings.Join(got, ""))
+		}
+		changes := 0
+		for _, e := range edits {
+			if e.op != ' ' {
+				changes++
+			}
+		}
+		if changes != tt.want {
+			t.Errorf("diffLines(%q, %q) made %d changes, want %d", tt.a, tt.b, changes, tt.want)
+		}
+	}
+}
+
+// Texts far too big for a quadratic table must still diff quickly and correctly
+func TestDiffLinesLarge(t *testing.T) {
+	var a, b []string
+	for i := 0; i < 50000; i++ {
+		a = append(a, fmt.Sprint(i))
+		if i%7 != 0 {
+			b = append(b, fmt.Sprint(i))
+		}
+		if i%11 == 0 {
+			b = append(b, "new")
+		}
+	}
+	got := applyEdits(t, a, diffLines(a, b, nil))
+	if strings.Join(got, "\n") != strings.Join(b, "\n") {
+		t.Fatal("diffLines does not turn a into b")
+	}
+}
+
+// Helper function that checks the kept and removed lines of an edit script against a and returns the lines it builds
+func applyEdits(t *testing.T, a []string, edits []edit) []string {
+	t.Helper()
+	out := []string{}
+	i := 0
+	for _, e := range edits {
+		switch e.op {
+		case ' ', '-':
+			if i >= len(a) || a[i] != e.line {
+				t.Fatalf("edit %c%q does not match line %d", e.op, e.line, i)
+			}
+			i++
+			if e.op == ' ' {
+				out = append(out, e.line)
+			}
+		case '+':
+			out = append(out, e.line)
+		}
+	}
+	if i != len(a) {
+		t.Fatalf("edit script covers %d of %d lines", i, len(a))
+	}
+	return out
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"monkey/evaluator"
+	"monkey/macro"
+	"monkey/object"
+	"monkey/token"
+	"os"
+	"os/signal"
+	"strings"
+)
+
+// monkey run [-max-steps n] [-max-depth n] [-timeout d] [-max-memory n] [-memstats] [file]
+func runCommand(args []string) int {
+	flags := flag.NewFlagSet("monkey run", flag.ExitOnError)
+	var limits evaluator.Limits
+	flags.IntVar(&limits.MaxSteps, "max-steps", 0, "stop after evaluating `n` nodes, 0 for no limit")
+	flags.IntVar(&limits.MaxDepth, "max-depth", 10000, "stop when `n` calls run at once, 0 for no limit")
+	flags.DurationVar(&limits.Timeout, "timeout", 0, "stop after running for `d`, 0 for no limit")
+	flags.Int64Var(&limits.MaxMemory, "max-memory", 0, "stop when the values of the program take up more than `n` bytes, 0 for no limit")
+	memstats := flags.Bool("memstats", false, "print the memory the values of the program took up to standard error")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: monkey run [-max-steps n] [-max-depth n] [-timeout d] [-max-memory n] [-memstats] [file]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+
+	name := flags.Arg(0)
+	src, err := readSource(name)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	program, ok := parseSource(name, src, nil)
+	if !ok {
+		return 1
+	}
+	diags := macro.Expand(program)
+	printDiagnostics(name, diags)
+	if diags.HasErrors() {
+		return 1
+	}
+	// an interrupt stops the program, which prints where it was
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	interpreter := evaluator.New(limits)
+	result := interpreter.Eval(ctx, program, object.NewEnvironment())
+	if *memstats {
+		mem := interpreter.Memory()
+		fmt.Fprintf(os.Stderr, "memory: %d bytes at peak, %d in use at the end, %d allocated\n", mem.Peak, mem.InUse, mem.Allocated)
+	}
+	if err, ok := result.(*object.Error); ok {
+		fmt.Fprint(os.Stderr, traceback(sourceName(name), src, err))
+		return 1
+	}
+	if result != nil && result != evaluator.NULL {
+		fmt.Println(result.Inspect())
+	}
+	return 0
+}
+
+/** Traceback **/
+// The calls a runtime error unwound are printed the way Python prints them, the most recent call last:
+//
+//	Traceback (most recent call last):
+//	  fact.mk:4:5, in <program>
+//	    fact(3)
+//	        ^
+//	  fact.mk:2:29, in fact
+//	    if (n == 0) { 1 / n } else { n * fact(n - 1) }
+//	                    ^
+//	error: division by zero
+
+// Helper function that formats the traceback of err, which happened running src, read from the named file
+func traceback(name string, src []byte, err *object.Error) string {
+	lines := strings.Split(string(src), "\n")
+	var out strings.Builder
+	out.WriteString("Traceback (most recent call last):\n")
+	// each call is made by the function of the frame before it, the first one by the program
+	function := "<program>"
+	repeated := 0
+	for i := len(err.Stack) - 1; i >= 0; i-- {
+		// deep recursion makes the same call over and over, which is shown a few times only
+		if i < len(err.Stack)-1 && err.Stack[i] == err.Stack[i+1] {
+			if repeated++; repeated >= maxRepeatedCalls {
+				continue
+			}
+		} else {
+			writeRepeated(&out, repeated)
+			repeated = 0
+		}
+		writeLocation(&out, name, lines, err.Stack[i].Call, function)
+		function = err.Stack[i].Function
+	}
+	writeRepeated(&out, repeated)
+	writeLocation(&out, name, lines, err.Pos, function)
+	fmt.Fprintf(&out, "error: %s\n", err.Message)
+	return out.String()
+}
+
+// The number of times the same call is shown in a row
+const maxRepeatedCalls = 3
+
+// Helper function that notes the calls left out of a traceback, after a call was repeated times
+func writeRepeated(out *strings.Builder, repeated int) {
+	if repeated >= maxRepeatedCalls {
+		fmt.Fprintf(out, "  [previous call repeated %d more times]\n", repeated-maxRepeatedCalls+1)
+	}
+}
+
+// Helper function that writes the position pos in function, followed by its source line with a caret under pos
+func writeLocation(out *strings.Builder, name string, lines []string, pos token.Position, function string) {
+	if pos.Line == 0 || pos.Line > len(lines) {
+		// code made by a macro may have no position
+		fmt.Fprintf(out, "  %s, in %s\n", name, function)
+		return
+	}
+	fmt.Fprintf(out, "  %s:%s, in %s\n", name, pos, function)
+	line := strings.TrimRight(lines[pos.Line-1], "\r")
+	indent := len(line) - len(strings.TrimLeft(line, " \t"))
+	col := pos.Column - 1
+	if col < indent || col > len(line) {
+		col = indent
+	}
+	// the caret lines up with the column under tabs too
+	caret := strings.Map(func(r rune) rune {
+		if r == '\t' {
+			return r
+		}
+		return ' '
+	}, line[indent:col])
+	fmt.Fprintf(out, "    %s\n    %s^\n", line[indent:], caret)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"monkey/evaluator"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"strings"
+	"testing"
+)
+
+func TestTraceback(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"let a = 1;\na + true", `Traceback (most recent call last):
+  x.mk:2:3, in <program>
+    a + true
+      ^
+error: type mismatch: INTEGER + BOOLEAN
+`},
+		{"let fact = fn(n) {\n\tif (n == 0) { 1 / n } else { n * fact(n - 1) }\n};\nfact(1);", `Traceback (most recent call last):
+  x.mk:4:5, in <program>
+    fact(1);
+        ^
+  x.mk:2:39, in fact
+    if (n == 0) { 1 / n } else { n * fact(n - 1) }
+                                         ^
+  x.mk:2:18, in fact
+    if (n == 0) { 1 / n } else { n * fact(n - 1) }
+                    ^
+error: division by zero
+`},
+		// the caret keeps the tabs inside the line
+		{"let f = fn() {\n\t1 +\t\tf };\nf()", `Traceback (most recent call last):
+  x.mk:3:2, in <program>
+    f()
+     ^
+  x.mk:2:4, in f
+    1 +		f };
+      ^
+error: type mismatch: INTEGER + FUNCTION
+`},
+		{"(fn() { -true })()", `Traceback (most recent call last):
+  x.mk:1:17, in <program>
+    (fn() { -true })()
+                    ^
+  x.mk:1:9, in <anonymous>
+    (fn() { -true })()
+            ^
+error: unknown operator: -BOOLEAN
+`},
+	}
+	for _, tt := range tests {
+		program := parser.New(lexer.New(tt.src)).ParseProgram()
+		err, ok := evaluator.Eval(program, object.NewEnvironment()).(*object.Error)
+		if !ok {
+			t.Errorf("%q: got no error", tt.src)
+			continue
+		}
+		if got := traceback("x.mk", []byte(tt.src), err); got != tt.want {
+			t.Errorf("%q:\n%s\nwant:\n%s", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestTracebackWithoutPosition(t *testing.T) {
+	err := &object.Error{Message: "boom", Stack: []object.Frame{{Function: "f"}}}
+	want := "Traceback (most recent call last):\n  x.mk, in <program>\n  x.mk, in f\nerror: boom\n"
+	if got := traceback("x.mk", []byte("f()"), err); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestTracebackRepeatedCalls(t *testing.T) {
+	src := "let f = fn() { 1 + f() };\nf()"
+	program := parser.New(lexer.New(src)).ParseProgram()
+	result := evaluator.New(evaluator.Limits{MaxDepth: 10}).Eval(context.Background(), program, object.NewEnvironment())
+	err, ok := result.(*object.Error)
+	if !ok {
+		t.Fatalf("got %s, want an error", result.Inspect())
+	}
+	call := "  x.mk:1:21, in f\n    let f = fn() { 1 + f() };\n                        ^\n"
+	want := "Traceback (most recent call last):\n  x.mk:2:2, in <program>\n    f()\n     ^\n" +
+		strings.Repeat(call, 3) + "  [previous call repeated 6 more times]\n" + call +
+		"error: call depth limit exceeded: more than 10 calls\n"
+	if got := traceback("x.mk", []byte(src), err); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
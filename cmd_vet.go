@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"monkey/vet"
+	"os"
+)
+
+// monkey vet [-config file] [-json] [files...]
+func vetCommand(args []string) int {
+	flags := flag.NewFlagSet("monkey vet", flag.ExitOnError)
+	configName := flags.String("config", "", "read the rules to enable from `file` (default "+vet.ConfigFile+" if it exists)")
+	asJSON := flags.Bool("json", false, "print the issues as a JSON array")
+	list := flags.Bool("rules", false, "list the rules and exit")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: monkey vet [-config file] [-json] [files...]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if *list {
+		for _, rule := range vet.Rules {
+			fmt.Printf("%-20s %s\n", rule.Name, rule.Doc)
+		}
+		return 0
+	}
+	config, err := loadVetConfig(*configName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	names := flags.Args()
+	if len(names) == 0 {
+		names = []string{""}
+	}
+	status := 0
+	all := []jsonIssue{}
+	for _, name := range names {
+		src, err := readSource(name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+			continue
+		}
+		program, ok := parseSource(name, src, nil)
+		if !ok {
+			status = 1
+			continue
+		}
+		for _, issue := range vet.Vet(program, src, config) {
+			status = 1
+			if *asJSON {
+				all = append(all, jsonIssue{
+					File: sourceName(name), Line: issue.Pos.Line, Column: issue.Pos.Column,
+					Rule: issue.Rule, Severity: issue.Severity.String(), Message: issue.Msg,
+				})
+				continue
+			}
+			fmt.Printf("%s:%s (%s)\n", sourceName(name), issue.Diagnostic, issue.Rule)
+		}
+	}
+	if *asJSON {
+		data, err := json.MarshalIndent(all, "", "  ")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		fmt.Println(string(data))
+	}
+	return status
+}
+
+// The JSON form of a vet.Issue
+type jsonIssue struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// Helper function that reads the named config file, or vet.ConfigFile if there is one when
+// name is empty. No file means every rule is enabled.
+func loadVetConfig(name string) (*vet.Config, error) {
+	if name == "" {
+		if _, err := os.Stat(vet.ConfigFile); err != nil {
+			return nil, nil
+		}
+		name = vet.ConfigFile
+	}
+	return vet.LoadConfig(name)
+}
@@ -0,0 +1,191 @@
+// Package cst builds a lossless concrete syntax tree alongside the ast.
+//
+// Every byte of the source belongs to exactly one Token: its literal text or the whitespace and
+// comments (the trivia) around it. Printing a File gives back the source byte for byte, so tools can
+// edit a few tokens and write the program back with every untouched region left as it was.
+package cst
+
+import (
+	"bytes"
+	"fmt"
+	"monkey/ast"
+	"monkey/lexer"
+	"monkey/parser"
+	"monkey/token"
+	"sort"
+	"strings"
+)
+
+// An Element is a child of a Node: either a *Node or a *Token
+type Element interface {
+	String() string // The exact source text of the element, trivia included
+	element()
+}
+
+// A Token is a token.Token with the trivia that surrounds it
+type Token struct {
+	token.Token
+	Leading  string // The whitespace and comments before the token
+	Trailing string // The whitespace and comments after the token, up to and including the end of its line
+}
+
+func (t *Token) element() {}
+func (t *Token) String() string {
+	return t.Leading + t.Literal + t.Trailing
+}
+
+// A Node covers the tokens an ast.Node was parsed from
+type Node struct {
+	Kind     string   // The ast type name, e.g. "INFIX_Expression"
+	AST      ast.Node // The ast node the CST node was built from
+	Children []Element
+}
+
+func (n *Node) element() {}
+func (n *Node) String() string {
+	var out bytes.Buffer
+	for _, child := range n.Children {
+		out.WriteString(child.String())
+	}
+	return out.String()
+}
+
+// Tokens returns the tokens under the node, in source order
+func (n *Node) Tokens() []*Token {
+	tokens := []*Token{}
+	for _, child := range n.Children {
+		switch c := child.(type) {
+		case *Token:
+			tokens = append(tokens, c)
+		case *Node:
+			tokens = append(tokens, c.Tokens()...)
+		}
+	}
+	return tokens
+}
+
+// A File is the root of the tree, covering the whole source including the EOF token and the trivia before it
+type File struct {
+	Node
+	nodes map[ast.Node]*Node
+}
+
+// NodeOf returns the CST node built from an ast node of the file, or nil
+func (f *File) NodeOf(node ast.Node) *Node {
+	return f.nodes[node]
+}
+
+// Program converts the tree to an ast.Program. It parses the current text of the tree,
+// so it reflects any edits made to the tokens since Parse.
+func (f *File) Program() (*ast.Program, error) {
+	ps := parser.New(lexer.New(f.String()))
+	program := ps.ParseProgram()
+	if len(ps.Errors()) > 0 {
+		return nil, ps.Errors()
+	}
+	return program, nil
+}
+
+// Parse parses src into a lossless tree. The returned error is a parser.ErrorList.
+func Parse(src string) (*File, error) {
+	ps := parser.New(lexer.New(src))
+	ps.RecordSpans()
+	program := ps.ParseProgram()
+	if len(ps.Errors()) > 0 {
+		return nil, ps.Errors()
+	}
+	tokens, err := attachTrivia(src, ps.Tokens())
+	if err != nil {
+		return nil, err
+	}
+	file := &File{Node: Node{Kind: "Program", AST: program}, nodes: map[ast.Node]*Node{}}
+	file.nodes[program] = &file.Node
+	if err := file.build(tokens, ps.Spans()); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// Helper function that pairs every token up to EOF with the source text around it
+func attachTrivia(src string, tokens []token.Token) ([]*Token, error) {
+	result := []*Token{}
+	end := 0 // The offset just past the previous token
+	for _, tok := range tokens {
+		start := tok.Pos.Offset
+		if start < end || start+len(tok.Literal) > len(src) || src[start:start+len(tok.Literal)] != tok.Literal {
+			return nil, fmt.Errorf("cst: token %q at %s does not match the source", tok.Literal, tok.Pos)
+		}
+		gap := src[end:start]
+		if len(result) == 0 {
+			result = append(result, &Token{Token: tok, Leading: gap})
+		} else {
+			// The previous token keeps the trivia on its own line, the new token gets the rest
+			split := len(gap)
+			if i := strings.IndexByte(gap, '\n'); i >= 0 {
+				split = i + 1
+			}
+			result[len(result)-1].Trailing = gap[:split]
+			result = append(result, &Token{Token: tok, Leading: gap[split:]})
+		}
+		end = start + len(tok.Literal)
+		if tok.Type == token.EOF {
+			return result, nil
+		}
+	}
+	return nil, fmt.Errorf("cst: missing EOF token")
+}
+
+// Helper function that nests the spans recorded by the parser into a tree of Nodes,
+// filling the gaps between child nodes with the tokens themselves
+func (f *File) build(tokens []*Token, spans []parser.NodeSpan) error {
+	order := make([]int, len(spans))
+	for i := range order {
+		order[i] = i
+	}
+	// Outer nodes first: by start, then by end, then the node recorded last (the parent) first
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := spans[order[i]], spans[order[j]]
+		if a.Start != b.Start {
+			return a.Start < b.Start
+		}
+		if a.End != b.End {
+			return a.End > b.End
+		}
+		return order[i] > order[j]
+	})
+
+	type open struct {
+		node *Node
+		end  int
+	}
+	stack := []open{{&f.Node, len(tokens) - 1}}
+	next := 0 // The next token to place
+	flush := func(node *Node, upto int) {
+		for ; next <= upto; next++ {
+			node.Children = append(node.Children, tokens[next])
+		}
+	}
+	for _, i := range order {
+		span := spans[i]
+		for span.Start > stack[len(stack)-1].end {
+			top := stack[len(stack)-1]
+			flush(top.node, top.end)
+			stack = stack[:len(stack)-1]
+		}
+		top := stack[len(stack)-1]
+		if span.End > top.end || span.Start < next {
+			return fmt.Errorf("cst: %T at %s overlaps its neighbours", span.Node, tokens[span.Start].Pos)
+		}
+		flush(top.node, span.Start-1)
+		node := &Node{Kind: strings.TrimPrefix(fmt.Sprintf("%T", span.Node), "*ast."), AST: span.Node}
+		top.node.Children = append(top.node.Children, node)
+		f.nodes[span.Node] = node
+		stack = append(stack, open{node, span.End})
+	}
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		flush(top.node, top.end)
+		stack = stack[:len(stack)-1]
+	}
+	return nil
+}
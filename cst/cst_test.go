@@ -0,0 +1,110 @@
+package cst
+
+import (
+	"monkey/ast"
+	"strings"
+	"testing"
+)
+
+var sources = []string{
+	"",
+	"   \n\n",
+	"// only a comment",
+	"let x = 1;",
+	"let   x=1 ;   // one\n\n\n// two\nlet y =\tx  +  2\n",
+	"\n\n  let f = fn( a ,b = 2, ...rest ) {\n\t// body\n\n   a+b  ;\n}\r\n",
+	"let [ head , ...tail ] = xs;\nlet {name, age:years} = person; // destructure\n",
+	"match ( x ) {\n  1 => 10 ,   // one\n  n if n > 0=>n,\n  _ => 0,\n}\n",
+	"xs |> map( x=>x*2 )   |> sum\n// trailing comment without newline",
+	"if(a<b){a}else   {  b  }",
+	"f(1,  b : 2)\n\n\n\n",
+	"(((1)))",
+	"-a * !b",
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, src := range sources {
+		file, err := Parse(src)
+		if err != nil {
+			t.Errorf("%q: %s", src, err)
+			continue
+		}
+		if got := file.String(); got != src {
+			t.Errorf("printed %q, want %q", got, src)
+		}
+		tokens := file.Tokens()
+		if last := tokens[len(tokens)-1]; last.Literal != "" {
+			t.Errorf("%q: last token is %q, want EOF", src, last.Literal)
+		}
+	}
+}
+
+func TestTrivia(t *testing.T) {
+	file, err := Parse("let x = 1; // one\n// two\nx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens := file.Tokens()
+	semicolon, x := tokens[4], tokens[5]
+	if semicolon.Literal != ";" || semicolon.Trailing != " // one\n" {
+		t.Errorf("%q has trailing trivia %q, want the rest of its line", semicolon.Literal, semicolon.Trailing)
+	}
+	if x.Literal != "x" || x.Leading != "// two\n" {
+		t.Errorf("%q has leading trivia %q, want the comment line above it", x.Literal, x.Leading)
+	}
+}
+
+func TestNodes(t *testing.T) {
+	file, err := Parse("let total = price  *  qty; // cost\ntotal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	program := file.AST.(*ast.Program)
+	let := program.Statements[0].(*ast.LET_Statement)
+	node := file.NodeOf(let.Value)
+	if node == nil {
+		t.Fatalf("no node for %s", let.Value.Node_String())
+	}
+	if node.Kind != "INFIX_Expression" {
+		t.Errorf("kind is %s, want INFIX_Expression", node.Kind)
+	}
+	if strings.TrimSpace(node.String()) != "price  *  qty" {
+		t.Errorf("node text is %q", node.String())
+	}
+	if stmt := file.NodeOf(let); stmt == nil || stmt.String() != "let total = price  *  qty; // cost\n" {
+		t.Errorf("let statement text is %q", stmt)
+	}
+	if len(file.Children) != 3 {
+		t.Errorf("the file has %d children, want two statements and EOF", len(file.Children))
+	}
+}
+
+func TestEditAndConvert(t *testing.T) {
+	src := "// rates\nlet tax = 10;   // percent\n\nlet price = fn(x) { x + x * tax / 100 };\n"
+	file, err := Parse(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tok := range file.Tokens() {
+		if tok.Literal == "10" {
+			tok.Literal = "20"
+		}
+	}
+	want := strings.Replace(src, "10;", "20;", 1)
+	if file.String() != want {
+		t.Errorf("edited file is %q, want %q", file.String(), want)
+	}
+	program, err := file.Program()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := program.Statements[0].Node_String(); got != "let tax = 20;" {
+		t.Errorf("converted statement is %s", got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	if _, err := Parse("let x 1"); err == nil || err.Error() != "1:7: expected next token to be =, got INT instead" {
+		t.Errorf("got error %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+// Package diag holds the positioned errors and warnings that the passes over a parsed program report.
+package diag
+
+import (
+	"fmt"
+	"monkey/token"
+	"sort"
+)
+
+type Severity int
+
+const (
+	Error   Severity = iota // The program is wrong and should not be run
+	Warning                 // The program is likely not what was meant
+)
+
+func (s Severity) String() string {
+	if s == Warning {
+		return "warning"
+	}
+	return "error"
+}
+
+// A Diagnostic is a message about a position in the source
+type Diagnostic struct {
+	Pos      token.Position
+	Severity Severity
+	Msg      string
+}
+
+func (d *Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s: %s", d.Pos, d.Severity, d.Msg)
+}
+
+// A List of diagnostics, in the order they were reported until it is sorted
+type List []*Diagnostic
+
+// Errorf reports an error at pos
+func (l *List) Errorf(pos token.Position, format string, args ...interface{}) {
+	*l = append(*l, &Diagnostic{Pos: pos, Severity: Error, Msg: fmt.Sprintf(format, args...)})
+}
+
+// Warnf reports a warning at pos
+func (l *List) Warnf(pos token.Position, format string, args ...interface{}) {
+	*l = append(*l, &Diagnostic{Pos: pos, Severity: Warning, Msg: fmt.Sprintf(format, args...)})
+}
+
+// HasErrors reports whether any diagnostic in the list is an Error
+func (l List) HasErrors() bool {
+	for _, d := range l {
+		if d.Severity == Error {
+			return true
+		}
+	}
+	return false
+}
+
+// Sort orders the list by position, keeping the report order of diagnostics at the same position
+func (l List) Sort() {
+	sort.SliceStable(l, func(i, j int) bool {
+		return l[i].Pos.Offset < l[j].Pos.Offset
+	})
+}
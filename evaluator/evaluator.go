@@ -0,0 +1,419 @@
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"monkey/ast"
+	"monkey/object"
+	"monkey/token"
+)
+
+// There is only ever one null, true and false
+var (
+	NULL  = &object.Null{}
+	TRUE  = &object.Boolean{Value: true}
+	FALSE = &object.Boolean{Value: false}
+)
+
+// Eval evaluates node in env and returns its value, an *object.Error if evaluation failed.
+// It sets no limits, see Interpreter for running a program that might not stop.
+func Eval(node ast.Node, env *object.Environment) object.Object {
+	return New(Limits{}).Eval(context.Background(), node, env)
+}
+
+// Helper function that evaluates node, which is in tail position of a function body if tail is set.
+// A call in tail position is not made but returned as a *tailCall, see applyFunction.
+func (in *Interpreter) eval(node ast.Node, env *object.Environment, tail bool) object.Object {
+	if err := in.step(); err != nil {
+		return errorAt(ast.Pos(node), err)
+	}
+	switch node := node.(type) {
+	/** Statements **/
+	case *ast.Program:
+		return in.evalProgram(node, env)
+	case *ast.BlockStatement:
+		return in.evalBlockStatement(node, env, tail)
+	case *ast.EXPRESSION_Statement:
+		return in.eval(node.Expression, env, tail)
+	case *ast.RETURN_Statement:
+		val := in.eval(node.ReturnValue, env, tail)
+		if isError(val) {
+			return val
+		}
+		return &object.ReturnValue{Value: val}
+	case *ast.LET_Statement:
+		val := in.eval(node.Value, env, false)
+		if isError(val) {
+			return val
+		}
+		// `let f = fn...` names the function, for stack traces
+		if fn, ok := val.(*object.Function); ok && fn.Name == "" {
+			if name, ok := node.Name.(*ast.Identifier); ok {
+				if _, ok := node.Value.(*ast.FunctionLiteral); ok {
+					fn.Name = name.Value
+				}
+			}
+		}
+		if err := in.bindPattern(node.Name, val, env); err != nil {
+			return errorAt(node.Token.Pos, err)
+		}
+
+	/** Expressions **/
+	case *ast.INTEGER_Literal:
+		return in.track(&object.Integer{Value: node.Value})
+	case *ast.Boolean:
+		return nativeBoolToBooleanObject(node.Value)
+	case *ast.Identifier:
+		return errorAt(node.Token.Pos, evalIdentifier(node, env))
+	case *ast.PREFIX_Expression:
+		right := in.eval(node.Right, env, false)
+		if isError(right) {
+			return right
+		}
+		return errorAt(node.Token.Pos, in.track(evalPrefixExpression(node.Token.Literal, right)))
+	case *ast.INFIX_Expression:
+		left := in.eval(node.Left, env, false)
+		if isError(left) {
+			return left
+		}
+		right := in.eval(node.Right, env, false)
+		if isError(right) {
+			return right
+		}
+		return errorAt(node.Token.Pos, in.track(evalInfixExpression(node.Token.Literal, left, right)))
+	case *ast.IF_Expression:
+		return in.evalIFExpression(node, env, tail)
+	case *ast.FunctionLiteral:
+		return in.track(&object.Function{Literal: node, Env: env})
+	case *ast.CALL_Expression:
+		if isQuoteForm(node) {
+			return errorAt(callPos(node), in.evalQuoteForm(node, env))
+		}
+		return errorAt(callPos(node), in.evalCallExpression(node, env, tail))
+	case *ast.MATCH_Expression:
+		return errorAt(node.Token.Pos, in.evalMatchExpression(node, env, tail))
+	case *ast.KEYWORD_Argument:
+		return errorAt(ast.Pos(node), newError("keyword argument %s outside of a call", node.Name.Value))
+	case *ast.MacroLiteral:
+		return errorAt(node.Token.Pos, newError("macro outside of macro expansion: expand the program with package macro before running it"))
+	}
+	return nil
+}
+
+/** Evaluate Program **/
+func (in *Interpreter) evalProgram(program *ast.Program, env *object.Environment) object.Object {
+	var result object.Object
+	for _, stmt := range program.Statements {
+		result = in.eval(stmt, env, false)
+		switch result := result.(type) {
+		case *object.ReturnValue:
+			return result.Value
+		case *object.Error:
+			return result
+		}
+	}
+	return result
+}
+
+/** Evaluate Block Statement **/
+// unlike evalProgram it leaves a ReturnValue wrapped so that it unwinds the enclosing blocks too.
+// In a block in tail position the last statement is in tail position, and so is every return.
+func (in *Interpreter) evalBlockStatement(block *ast.BlockStatement, env *object.Environment, tail bool) object.Object {
+	var result object.Object
+	for i, stmt := range block.Statemens {
+		_, isReturn := stmt.(*ast.RETURN_Statement)
+		result = in.eval(stmt, env, tail && (isReturn || i == len(block.Statemens)-1))
+		if result != nil {
+			rt := result.Type()
+			if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ {
+				return result
+			}
+		}
+	}
+	return result
+}
+
+/** Evaluate Identifier **/
+func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
+	if val, ok := env.Get(node.Value); ok {
+		return val
+	}
+	return newError("identifier not found: %s", node.Value)
+}
+
+/** Evaluate Prefix Expression **/
+func evalPrefixExpression(operator string, right object.Object) object.Object {
+	switch operator {
+	case "!":
+		return nativeBoolToBooleanObject(!isTruthy(right))
+	case "-":
+		if right.Type() != object.INTEGER_OBJ {
+			return newError("unknown operator: -%s", right.Type())
+		}
+		return &object.Integer{Value: -right.(*object.Integer).Value}
+	}
+	return newError("unknown operator: %s%s", operator, right.Type())
+}
+
+/** Evaluate Infix Expression **/
+func evalInfixExpression(operator string, left, right object.Object) object.Object {
+	switch {
+	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
+		return evalIntegerInfixExpression(operator, left.(*object.Integer).Value, right.(*object.Integer).Value)
+	case left.Type() != right.Type():
+		return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
+	case operator == "==":
+		return nativeBoolToBooleanObject(left == right)
+	case operator == "!=":
+		return nativeBoolToBooleanObject(left != right)
+	}
+	return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+}
+
+func evalIntegerInfixExpression(operator string, left, right int64) object.Object {
+	switch operator {
+	case "+":
+		return &object.Integer{Value: left + right}
+	case "-":
+		return &object.Integer{Value: left - right}
+	case "*":
+		return &object.Integer{Value: left * right}
+	case "/":
+		if right == 0 {
+			return newError("division by zero")
+		}
+		return &object.Integer{Value: left / right}
+	case "<":
+		return nativeBoolToBooleanObject(left < right)
+	case ">":
+		return nativeBoolToBooleanObject(left > right)
+	case "==":
+		return nativeBoolToBooleanObject(left == right)
+	case "!=":
+		return nativeBoolToBooleanObject(left != right)
+	}
+	return newError("unknown operator: INTEGER %s INTEGER", operator)
+}
+
+/** Evaluate IF Expression **/
+func (in *Interpreter) evalIFExpression(ie *ast.IF_Expression, env *object.Environment, tail bool) object.Object {
+	condition := in.eval(ie.Condition, env, false)
+	if isError(condition) {
+		return condition
+	}
+	if isTruthy(condition) {
+		return in.eval(ie.Consequence, env, tail)
+	}
+	if ie.Alternative != nil {
+		return in.eval(ie.Alternative, env, tail)
+	}
+	return NULL
+}
+
+/** Evaluate MATCH Expression **/
+// evaluates the body of the first arm whose pattern matches the subject and whose guard holds,
+// each arm binds the names of its pattern in an environment of its own
+func (in *Interpreter) evalMatchExpression(me *ast.MATCH_Expression, env *object.Environment, tail bool) object.Object {
+	subject := in.eval(me.Subject, env, false)
+	if isError(subject) {
+		return subject
+	}
+	for _, arm := range me.Arms {
+		armEnv := in.newEnvironment(env)
+		if !in.matchPattern(arm.Pattern, subject, armEnv) {
+			continue
+		}
+		if arm.Guard != nil {
+			guard := in.eval(arm.Guard, armEnv, false)
+			if isError(guard) {
+				return guard
+			}
+			if !isTruthy(guard) {
+				continue
+			}
+		}
+		return in.eval(arm.Body, armEnv, tail)
+	}
+	return newError("no match arm matches %s", subject.Inspect())
+}
+
+/** Evaluate CALL Expression **/
+func (in *Interpreter) evalCallExpression(ce *ast.CALL_Expression, env *object.Environment, tail bool) object.Object {
+	// the arguments belong to the call, nothing else can hold on to them
+	mark := in.memory.InUse
+	fn, fnEnv, err := in.bindCall(ce, env)
+	if err != nil {
+		return err
+	}
+	if tail {
+		return &tailCall{fn: fn, env: fnEnv, call: ce}
+	}
+	return in.applyFunction(fn, fnEnv, ce, mark)
+}
+
+// Helper function that evaluates the function and the arguments of the call ce and binds the
+// parameters of the function in the environment its body is to run in
+func (in *Interpreter) bindCall(ce *ast.CALL_Expression, env *object.Environment) (*object.Function, *object.Environment, object.Object) {
+	function := in.eval(ce.Function, env, false)
+	if isError(function) {
+		return nil, nil, function
+	}
+	fn, ok := function.(*object.Function)
+	if !ok {
+		return nil, nil, newError("not a function: %s", function.Type())
+	}
+	// the arguments are evaluated left to right before any of them is bound
+	values := map[ast.Expression]object.Object{}
+	for _, arg := range ce.Arguments {
+		expr := arg
+		if kw, ok := arg.(*ast.KEYWORD_Argument); ok {
+			expr = kw.Value
+		}
+		val := in.eval(expr, env, false)
+		if isError(val) {
+			return nil, nil, val
+		}
+		values[expr] = val
+	}
+	bindings, err := fn.Literal.BindArguments(ce.Arguments)
+	if err != nil {
+		return nil, nil, newError("%s", err)
+	}
+	// from here on the error happened in the function, whose call is a frame of the stack trace
+	fnEnv, errObj := in.bindParameters(fn, bindings, values)
+	if errObj != nil {
+		return nil, nil, addFrame(errObj, fn, ce)
+	}
+	return fn, fnEnv, nil
+}
+
+// A tailCall is a call in tail position of a function body that is yet to be made
+type tailCall struct {
+	fn   *object.Function
+	env  *object.Environment // The environment the parameters are bound in
+	call *ast.CALL_Expression
+}
+
+func (tc *tailCall) Type() object.ObjectType { return "TAIL_CALL" }
+func (tc *tailCall) Inspect() string         { return tc.call.Node_String() }
+
+// Helper function that runs the body of fn in env, where the call ce bound its parameters.
+// A call the body makes in tail position comes back as a *tailCall, which is made in place of
+// the one it returns from, so that tail-recursive functions run in constant stack space.
+// The frame of the call in a stack trace is replaced too, and so are the bytes it made, which
+// were counted from mark on.
+func (in *Interpreter) applyFunction(fn *object.Function, env *object.Environment, ce *ast.CALL_Expression, mark int64) object.Object {
+	in.depth++
+	defer func() { in.depth-- }()
+	if in.limits.MaxDepth > 0 && in.depth > in.limits.MaxDepth {
+		err := limitError(ErrDepthLimit, "%s: more than %d calls", ErrDepthLimit, in.limits.MaxDepth)
+		return errorAt(callPos(ce), err)
+	}
+	for {
+		result := unwrapReturnValue(in.eval(fn.Literal.Body, env, true))
+		tc, ok := result.(*tailCall)
+		if !ok {
+			in.release(mark, retained(result))
+			return addFrame(result, fn, ce)
+		}
+		// all that is left of this call is the environment of the next
+		in.release(mark, retainedEnvironment(tc.env))
+		fn, env, ce = tc.fn, tc.env, tc.call
+	}
+}
+
+// Helper function that records on an error that it unwound the call ce of fn
+func addFrame(obj object.Object, fn *object.Function, ce *ast.CALL_Expression) object.Object {
+	err, ok := obj.(*object.Error)
+	if !ok {
+		return obj
+	}
+	name := fn.Name
+	if name == "" {
+		name = "<anonymous>"
+	}
+	err.Stack = append(err.Stack, object.Frame{Function: name, Call: callPos(ce)})
+	return err
+}
+
+// Helper function that binds the parameters of fn in a new environment enclosed by the one fn was defined in.
+// A parameter left without an argument gets its default value, evaluated after the parameters before it are bound.
+func (in *Interpreter) bindParameters(fn *object.Function, bindings []ast.Binding, values map[ast.Expression]object.Object) (*object.Environment, object.Object) {
+	env := in.newEnvironment(fn.Env)
+	for _, b := range bindings {
+		var val object.Object
+		switch {
+		case b.Parameter.Variadic:
+			rest := &object.Array{Elements: []object.Object{}}
+			for _, arg := range b.Values {
+				rest.Elements = append(rest.Elements, values[arg])
+			}
+			val = in.track(rest)
+		case len(b.Values) == 0:
+			val = in.eval(b.Parameter.Default, env, false)
+			if isError(val) {
+				return nil, val
+			}
+		default:
+			val = values[b.Values[0]]
+		}
+		if err := in.bindPattern(b.Parameter.Name, val, env); err != nil {
+			return nil, errorAt(ast.Pos(b.Parameter.Name), err)
+		}
+	}
+	return env, nil
+}
+
+// Helper function that takes the value out of a return statement so it stops unwinding at the function boundary
+func unwrapReturnValue(obj object.Object) object.Object {
+	if rv, ok := obj.(*object.ReturnValue); ok {
+		return rv.Value
+	}
+	if obj == nil {
+		return NULL
+	}
+	return obj
+}
+
+// Helper function that returns one of the shared TRUE and FALSE objects
+func nativeBoolToBooleanObject(value bool) *object.Boolean {
+	if value {
+		return TRUE
+	}
+	return FALSE
+}
+
+// Helper function that tells whether a condition holds; only false and null do not
+func isTruthy(obj object.Object) bool {
+	switch obj {
+	case NULL, FALSE, nil:
+		return false
+	}
+	return true
+}
+
+func newError(format string, args ...interface{}) *object.Error {
+	return &object.Error{Message: fmt.Sprintf(format, args...)}
+}
+
+// Helper function that gives an error the position pos unless it knows where it happened already.
+// Errors are made without a position and get the one of the innermost node that returns them.
+func errorAt(pos token.Position, obj object.Object) object.Object {
+	if err, ok := obj.(*object.Error); ok && err.Pos.Line == 0 {
+		err.Pos = pos
+	}
+	return obj
+}
+
+// Helper function that returns the position of the parenthesis of a call, or the start of
+// a call written with the pipe operator, which has none
+func callPos(ce *ast.CALL_Expression) token.Position {
+	if ce.Token.Pos.Line == 0 {
+		return ast.Pos(ce)
+	}
+	return ce.Token.Pos
+}
+
+func isError(obj object.Object) bool {
+	return obj != nil && obj.Type() == object.ERROR_OBJ
+}
@@ -0,0 +1,255 @@
+package evaluator
+
+import (
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"reflect"
+	"runtime/debug"
+	"testing"
+)
+
+// Helper function that parses and evaluates input in a fresh environment
+func testEval(t *testing.T, input string) object.Object {
+	t.Helper()
+	ps := parser.New(lexer.New(input))
+	program := ps.ParseProgram()
+	if errs := ps.Errors(); len(errs) > 0 {
+		t.Fatalf("parsing %q: %s", input, errs)
+	}
+	return Eval(program, object.NewEnvironment())
+}
+
+// Helper function that evaluates each input and compares the Inspect of the result with the expected one
+func expectInspect(t *testing.T, tests []struct{ input, want string }) {
+	t.Helper()
+	for _, tt := range tests {
+		got := testEval(t, tt.input)
+		if got == nil {
+			t.Errorf("%q: got no value, want %s", tt.input, tt.want)
+			continue
+		}
+		if got.Inspect() != tt.want {
+			t.Errorf("%q: got %s, want %s", tt.input, got.Inspect(), tt.want)
+		}
+	}
+}
+
+func TestEvalExpressions(t *testing.T) {
+	expectInspect(t, []struct{ input, want string }{
+		{"5", "5"},
+		{"-5 + 10 * 2", "15"},
+		{"(5 + 10) / 3", "5"},
+		{"1 < 2", "true"},
+		{"1 == 2", "false"},
+		{"true != false", "true"},
+		{"!true", "false"},
+		{"!5", "false"},
+		{"if (1 < 2) { 10 }", "10"},
+		{"if (1 > 2) { 10 }", "null"},
+		{"if (1 > 2) { 10 } else { 20 }", "20"},
+		{"if (false) { 10 } else { if (true) { return 30; } 40 }", "30"},
+		{"let a = 5; let b = a * 2; b", "10"},
+		{"if (true) { if (true) { return 1; } return 2; }", "1"},
+	})
+}
+
+func TestEvalErrors(t *testing.T) {
+	expectInspect(t, []struct{ input, want string }{
+		{"x", "ERROR: identifier not found: x"},
+		{"1 + true", "ERROR: type mismatch: INTEGER + BOOLEAN"},
+		{"-true", "ERROR: unknown operator: -BOOLEAN"},
+		{"true + false", "ERROR: unknown operator: BOOLEAN + BOOLEAN"},
+		{"1 / 0", "ERROR: division by zero"},
+		{"let a = 1; a(2)", "ERROR: not a function: INTEGER"},
+	})
+}
+
+func TestStackTrace(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   string   // where the error happened
+		stack []string // `function call-position`, the innermost call first
+	}{
+		{"1 + true", "1:3", nil},
+		{"let f = fn() { x }; f()", "1:16", []string{"f 1:22"}},
+		{"let a = 1; a(2)", "1:13", nil},
+		{"let f = fn(a) { a }; f()", "1:23", nil},
+		{"let f = fn(a) { a }; f(1 / 0)", "1:26", nil},
+		{"let f = fn(n) { if (n == 0) { -true } else { 1 + f(n - 1) } }; f(2)", "1:31", []string{"f 1:51", "f 1:51", "f 1:65"}},
+		{"let apply = fn(g) { g() + 1 }; apply(fn() { 1 / 0 })", "1:47", []string{"<anonymous> 1:22", "apply 1:37"}},
+		// a call in tail position replaces the frame of the function it is made in
+		{"let f = fn(n) { if (n == 0) { -true } else { f(n - 1) } }; f(2)", "1:31", []string{"f 1:47"}},
+		{"let apply = fn(g) { g() }; apply(fn() { 1 / 0 })", "1:43", []string{"<anonymous> 1:22"}},
+		{"let g = fn(a, b = 1 / 0) { a }; g(1)", "1:21", []string{"g 1:34"}},
+		{"let h = fn([a]) { a }; let f = fn(...r) { h(r) }; f(1, 2)", "1:12", []string{"h 1:44", "f 1:52"}},
+		// only a let of a function literal names the function
+		{"let f = fn() { 1 / 0 }; let g = f; g()", "1:18", []string{"f 1:37"}},
+		{"2 |> fn(x) { x / 0 }", "1:16", []string{"<anonymous> 1:1"}},
+	}
+	for _, tt := range tests {
+		err, ok := testEval(t, tt.input).(*object.Error)
+		if !ok {
+			t.Errorf("%q: got no error", tt.input)
+			continue
+		}
+		var stack []string
+		for _, frame := range err.Stack {
+			stack = append(stack, frame.Function+" "+frame.Call.String())
+		}
+		if err.Pos.String() != tt.pos || !reflect.DeepEqual(stack, tt.stack) {
+			t.Errorf("%q: got error at %s with stack %q, want %s with %q", tt.input, err.Pos, stack, tt.pos, tt.stack)
+		}
+	}
+}
+
+func TestFunctionCalls(t *testing.T) {
+	expectInspect(t, []struct{ input, want string }{
+		{"let add = fn(a, b) { a + b }; add(1, 2)", "3"},
+		{"let f = fn(a, b = 2) { a * b }; f(5)", "10"},
+		{"let f = fn(a, b = 2) { a * b }; f(5, 3)", "15"},
+		{"let f = fn(a, b = 2) { a * b }; f(5, b: 4)", "20"},
+		{"let f = fn(a, b) { a - b }; f(b: 1, a: 10)", "9"},
+		{"let f = fn(a, b = a + 1) { b }; f(1)", "2"},
+		{"let f = fn(a, ...rest) { rest }; f(1, 2, 3)", "[2, 3]"},
+		{"let f = fn(a, ...rest) { rest }; f(1)", "[]"},
+		{"let f = fn(a = 1, ...rest) { a }; f(a: 7)", "7"},
+		{"let f = fn() { return 1; 2 }; f()", "1"},
+		{"let adder = fn(a) { fn(b) { a + b } }; adder(1)(2)", "3"},
+		// annotations are checked statically and play no part at run time
+		{"let x: int = 4; let f = fn(a: int = 1, ...r: [int]): int { a * x }; f()", "4"},
+	})
+}
+
+func TestTailCalls(t *testing.T) {
+	// a tail-recursive loop needs no more stack than one call, so it fails loudly if calls pile up
+	defer debug.SetMaxStack(debug.SetMaxStack(8 << 20))
+	expectInspect(t, []struct{ input, want string }{
+		{"let loop = fn(n) { if (n == 0) { 0 } else { loop(n - 1) } }; loop(1000000)", "0"},
+		{"let sum = fn(n, acc) { if (n == 0) { return acc; } return sum(n - 1, acc + n); }; sum(100000, 0)", "5000050000"},
+		{"let even = fn(n) { if (n == 0) { true } else { odd(n - 1) } }; let odd = fn(n) { if (n == 0) { false } else { even(n - 1) } }; even(100001)", "false"},
+		{"let count = fn(n) { match (n) { 0 => 0, _ => count(n - 1) } }; count(100000)", "0"},
+		{"let f = fn(n, acc = 0) { if (n == 0) { acc } else { f(n - 1, acc: acc + 1) } }; f(100000)", "100000"},
+		{"let f = fn(n) { if (n == 0) { 0 } else { n |> fn(m) { f(m - 1) } } }; f(100000)", "0"},
+		// calls that are not in tail position are made as before
+		{"let fact = fn(n) { if (n < 2) { 1 } else { n * fact(n - 1) } }; fact(10)", "3628800"},
+		{"let g = fn() { 1 }; let f = fn() { let x = g(); x + 1 }; f()", "2"},
+		{"let k = fn(n) { if (n == 0) { fn() { 7 } } else { k(n - 1) } }; k(3)()", "7"},
+		{"let id = fn(x) { x }; let f = fn() { return id(1); 2 }; f()", "1"},
+	})
+}
+
+func TestArgumentErrors(t *testing.T) {
+	expectInspect(t, []struct{ input, want string }{
+		{"let f = fn(a, b) { a }; f(1)", "ERROR: wrong number of arguments: want 2, got 1"},
+		{"let f = fn(a, b) { a }; f(1, 2, 3)", "ERROR: wrong number of arguments: want 2, got 3"},
+		{"let f = fn(a, b = 1) { a }; f()", "ERROR: wrong number of arguments: want at least 1, got 0"},
+		{"let f = fn(a, b = 1) { a }; f(1, 2, 3)", "ERROR: wrong number of arguments: want at most 2, got 3"},
+		{"let f = fn(a, ...rest) { a }; f()", "ERROR: wrong number of arguments: want at least 1, got 0"},
+		{"let f = fn(a) { a }; f(b: 1)", "ERROR: unexpected keyword argument b"},
+		{"let f = fn(a, b) { a }; f(1, a: 2)", "ERROR: multiple values for parameter a"},
+		{"let f = fn(a, ...rest) { a }; f(1, rest: 2)", "ERROR: rest parameter rest cannot be passed by keyword"},
+		{"let f = fn(a, b = 2) { a }; f(b: 1)", "ERROR: missing argument for parameter a"},
+		{"let f = fn(a) { a }; f(x)", "ERROR: identifier not found: x"},
+	})
+}
+
+func TestDestructuring(t *testing.T) {
+	expectInspect(t, []struct{ input, want string }{
+		{"let list = fn(...xs) { xs }; let [a, b] = list(1, 2); a + b", "3"},
+		{"let list = fn(...xs) { xs }; let [head, ...tail] = list(1, 2, 3); tail", "[2, 3]"},
+		{"let list = fn(...xs) { xs }; let [head, ...tail] = list(1); tail", "[]"},
+		{"let list = fn(...xs) { xs }; let [a, [b, c]] = list(1, list(2, 3)); a * b * c", "6"},
+		{"let list = fn(...xs) { xs }; let first = fn([x, ...rest]) { x }; first(list(7, 8))", "7"},
+		{"let list = fn(...xs) { xs }; let f = fn([x] = list(9)) { x }; f()", "9"},
+	})
+}
+
+func TestDestructuringErrors(t *testing.T) {
+	expectInspect(t, []struct{ input, want string }{
+		{"let [a] = 1;", "ERROR: cannot destructure INTEGER with array pattern [a]"},
+		{"let {a} = true;", "ERROR: cannot destructure BOOLEAN with hash pattern {a}"},
+		{"let list = fn(...xs) { xs }; let [a, b] = list(1);", "ERROR: array pattern [a, b] does not match an array of length 1"},
+		{"let list = fn(...xs) { xs }; let [a] = list(1, 2);", "ERROR: array pattern [a] does not match an array of length 2"},
+		{"let list = fn(...xs) { xs }; let [a, b, ...c] = list(1);", "ERROR: array pattern [a, b, ...c] needs at least 2 elements, got 1"},
+		{"let list = fn(...xs) { xs }; let [a, {b}] = list(1, 2);", "ERROR: cannot destructure INTEGER with hash pattern {b}"},
+		{"let f = fn([x]) { x }; f(1)", "ERROR: cannot destructure INTEGER with array pattern [x]"},
+	})
+}
+
+func TestMatch(t *testing.T) {
+	expectInspect(t, []struct{ input, want string }{
+		{"match (1) { 1 => 10, _ => 20 }", "10"},
+		{"match (2) { 1 => 10, _ => 20 }", "20"},
+		{"match (-3) { -3 => true, _ => false }", "true"},
+		{"match (1 < 2) { false => 0, true => 1 }", "1"},
+		{"match (5) { n => n * 2 }", "10"},
+		{"match (5) { 1 => 0, 5 => 1, 5 => 2 }", "1"},
+		{"match (-4) { n if n > 0 => n, n => -n }", "4"},
+		{"match (4) { n if n > 0 => n, n => -n }", "4"},
+		{"let list = fn(...xs) { xs }; match (list()) { [] => 0, [x] => x, [x, ...rest] => rest }", "0"},
+		{"let list = fn(...xs) { xs }; match (list(1, 2, 3)) { [] => 0, [x] => x, [x, ...rest] => rest }", "[2, 3]"},
+		{"let list = fn(...xs) { xs }; match (list(1, 2)) { [2, y] => y, [1, y] => y * 10 }", "20"},
+		{"let list = fn(...xs) { xs }; match (list(list(1), 2)) { [[a], b] => a + b }", "3"},
+		{"match (1) { {a} => a, _ => 0 }", "0"},
+		{"match (true) { 1 => 0, _ => 1 }", "1"},
+		{"let n = 1; match (2) { n => n }; n", "1"},
+		{"let f = fn(x) { match (x) { 0 => 1, n => n * f(n - 1) } }; f(5)", "120"},
+	})
+}
+
+func TestMatchErrors(t *testing.T) {
+	expectInspect(t, []struct{ input, want string }{
+		{"match (3) { 1 => 10, 2 => 20 }", "ERROR: no match arm matches 3"},
+		{"match (3) {}", "ERROR: no match arm matches 3"},
+		{"match (3) { n if n > 5 => n }", "ERROR: no match arm matches 3"},
+		{"match (x) { _ => 1 }", "ERROR: identifier not found: x"},
+		{"match (1) { n if y => n }", "ERROR: identifier not found: y"},
+		{"match (1) { _ => 1 / 0 }", "ERROR: division by zero"},
+	})
+}
+
+func TestPipeAndArrowFunctions(t *testing.T) {
+	expectInspect(t, []struct{ input, want string }{
+		{"let double = x => x * 2; 3 |> double", "6"},
+		{"let add = (a, b) => a + b; 1 |> add(2) |> add(3)", "6"},
+		{"let sub = (a, b) => a - b; 10 |> sub(3)", "7"},
+		{"let f = fn(a, b = 1, c = 2) { a + b * c }; 5 |> f(c: 10)", "15"},
+		{"let apply = (f, x) => f(x); 4 |> apply(x => x * x, x: 1)", "ERROR: multiple values for parameter x"},
+		{"let compose = (f, g) => x => g(f(x)); let inc = x => x + 1; 1 |> compose(inc, inc)()", "3"},
+		{"let compose = (f, g) => x => g(f(x)); let inc = x => x + 1; let twice = compose(inc, inc); 1 |> twice", "3"},
+		{"let k = () => 42; k()", "42"},
+		{"let abs = n => match (n) { n if n < 0 => -n, n => n }; -5 |> abs", "5"},
+	})
+}
+
+func TestQuoteUnquote(t *testing.T) {
+	expectInspect(t, []struct{ input, want string }{
+		{"quote(5)", "QUOTE(5)"},
+		{"quote(5 + 8)", "QUOTE((5+8))"},
+		{"quote(foobar)", "QUOTE(foobar)"},
+		{"quote(fn(x) { x })", "QUOTE(fn(x) { x; })"},
+		{"quote(unquote(4 + 4))", "QUOTE(8)"},
+		{"quote(8 + unquote(4 - 8))", "QUOTE((8+-4))"},
+		{"quote(unquote(1 == 2) == unquote(true))", "QUOTE((false==true))"},
+		{"let q = quote(4 + 4); quote(unquote(q) * 2)", "QUOTE(((4+4)*2))"},
+		{"let q = quote(a); quote(unquote(q) + unquote(q))", "QUOTE((a+a))"},
+		{"quote(unquote(quote(b)))", "QUOTE(b)"},
+		{"let f = fn(n) { quote(unquote(n) + 1) }; f(2)", "QUOTE((2+1))"},
+		// code is data until it is spliced in, its names are not looked up
+		{"quote(x + unquote(1))", "QUOTE((x+1))"},
+	})
+}
+
+func TestQuoteUnquoteErrors(t *testing.T) {
+	expectInspect(t, []struct{ input, want string }{
+		{"quote()", "ERROR: quote takes 1 argument, got 0"},
+		{"quote(a, b)", "ERROR: quote takes 1 argument, got 2"},
+		{"quote(a: 1)", "ERROR: quote takes no keyword argument, got a"},
+		{"unquote(1)", "ERROR: unquote outside of quote"},
+		{"quote(unquote(1, 2))", "ERROR: unquote takes 1 argument, got 2"},
+		{"quote(unquote(x))", "ERROR: identifier not found: x"},
+		{"quote(unquote(fn(x) { x }))", "ERROR: cannot unquote fn(x) { x; }: only integers, booleans and quoted code can be turned into code"},
+		{"macro(x) { x }", "ERROR: macro outside of macro expansion: expand the program with package macro before running it"},
+	})
+}
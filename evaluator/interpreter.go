@@ -0,0 +1,78 @@
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"monkey/ast"
+	"monkey/object"
+	"time"
+)
+
+/** Interpreter **/
+// An Interpreter evaluates programs within limits, for running code that cannot be trusted to stop.
+// A program that exceeds a limit stops with an *object.Error that wraps one of the errors below,
+// or the error of the context it is run with, so the caller can tell it apart with errors.Is.
+
+var (
+	ErrStepLimit   = errors.New("step limit exceeded")
+	ErrDepthLimit  = errors.New("call depth limit exceeded")
+	ErrOutOfMemory = errors.New("out of memory")
+)
+
+// Limits bound the work of each call to Interpreter.Eval, a zero field sets no bound
+type Limits struct {
+	MaxSteps  int           // The number of nodes it may evaluate
+	MaxDepth  int           // The number of calls that may run at once, a tail call takes the place of its caller
+	Timeout   time.Duration // How long it may run
+	MaxMemory int64         // The bytes its objects may take up, with those left by earlier calls, see MemoryStats
+}
+
+type Interpreter struct {
+	limits Limits
+	done   <-chan struct{} // Closed when the context of the running Eval is done, nil if it never is
+	ctx    context.Context
+	steps  int
+	depth  int
+	memory MemoryStats
+}
+
+// Helper function to create an Interpreter with limits
+func New(limits Limits) *Interpreter {
+	return &Interpreter{limits: limits}
+}
+
+// Eval evaluates node in env like the package-level Eval, but stops when ctx is done or a limit is exceeded
+func (in *Interpreter) Eval(ctx context.Context, node ast.Node, env *object.Environment) object.Object {
+	if in.limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, in.limits.Timeout)
+		defer cancel()
+	}
+	in.ctx, in.done = ctx, ctx.Done()
+	in.steps, in.depth = 0, 0
+	return in.eval(node, env, false)
+}
+
+// Helper function that counts a step of evaluation and returns an error if it may not be taken
+func (in *Interpreter) step() *object.Error {
+	in.steps++
+	if in.limits.MaxSteps > 0 && in.steps > in.limits.MaxSteps {
+		return limitError(ErrStepLimit, "%s: more than %d steps", ErrStepLimit, in.limits.MaxSteps)
+	}
+	if in.limits.MaxMemory > 0 && in.memory.InUse > in.limits.MaxMemory {
+		return limitError(ErrOutOfMemory, "%s: more than %d bytes in use", ErrOutOfMemory, in.limits.MaxMemory)
+	}
+	// checking the context is cheap while it is not done, there is no need to do it less often
+	select {
+	case <-in.done:
+		return limitError(in.ctx.Err(), "evaluation stopped: %s", in.ctx.Err())
+	default:
+	}
+	return nil
+}
+
+// Helper function that returns an error for a limit err that evaluation ran into
+func limitError(err error, format string, args ...interface{}) *object.Error {
+	return &object.Error{Message: fmt.Sprintf(format, args...), Err: err}
+}
@@ -0,0 +1,115 @@
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"monkey/ast"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// Helper function that parses input, failing the test on a parse error
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+	ps := parser.New(lexer.New(input))
+	program := ps.ParseProgram()
+	if errs := ps.Errors(); len(errs) > 0 {
+		t.Fatalf("parsing %q: %s", input, errs)
+	}
+	return program
+}
+
+const (
+	forever   = "let f = fn() { f() }; f()"
+	recursive = "let f = fn(n) { if (n == 0) { 0 } else { 1 + f(n - 1) } }; f(100)"
+)
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		input  string
+		limits Limits
+		want   error // the error the program stops with, nil if it runs to the end
+		msg    string
+	}{
+		{recursive, Limits{}, nil, "100"},
+		{recursive, Limits{MaxSteps: 100000, MaxDepth: 101}, nil, "100"},
+		{recursive, Limits{MaxDepth: 100}, ErrDepthLimit, "call depth limit exceeded: more than 100 calls"},
+		{recursive, Limits{MaxSteps: 50}, ErrStepLimit, "step limit exceeded: more than 50 steps"},
+		{forever, Limits{MaxSteps: 10000}, ErrStepLimit, "step limit exceeded: more than 10000 steps"},
+		// a tail call does not count against the depth, so a loop can run for long
+		{"let loop = fn(n) { if (n == 0) { 0 } else { loop(n - 1) } }; loop(10000)", Limits{MaxDepth: 1}, nil, "0"},
+		{forever, Limits{Timeout: 20 * time.Millisecond}, context.DeadlineExceeded, "evaluation stopped: context deadline exceeded"},
+	}
+	for _, tt := range tests {
+		got := New(tt.limits).Eval(context.Background(), parse(t, tt.input), object.NewEnvironment())
+		err, isErr := got.(*object.Error)
+		switch {
+		case tt.want == nil && isErr:
+			t.Errorf("%q with %+v: unexpected error %s", tt.input, tt.limits, err.Message)
+		case tt.want == nil && got.Inspect() != tt.msg:
+			t.Errorf("%q with %+v: got %s, want %s", tt.input, tt.limits, got.Inspect(), tt.msg)
+		case tt.want != nil && (!isErr || !errors.Is(err, tt.want) || err.Message != tt.msg):
+			t.Errorf("%q with %+v: got %s, want error %q", tt.input, tt.limits, got.Inspect(), tt.msg)
+		}
+	}
+}
+
+func TestLimitErrorPosition(t *testing.T) {
+	got := New(Limits{MaxDepth: 3}).Eval(context.Background(), parse(t, recursive), object.NewEnvironment())
+	err, ok := got.(*object.Error)
+	if !ok {
+		t.Fatalf("got %s, want an error", got.Inspect())
+	}
+	// the call that would be one too many fails, in the three that run
+	if err.Pos.String() != "1:47" || len(err.Stack) != 3 {
+		t.Errorf("got error at %s with %d frames, want 1:47 with 3", err.Pos, len(err.Stack))
+	}
+}
+
+func TestCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	got := New(Limits{}).Eval(ctx, parse(t, forever), object.NewEnvironment())
+	if err, ok := got.(*object.Error); !ok || !errors.Is(err, context.Canceled) {
+		t.Errorf("got %s, want the program to be canceled", got.Inspect())
+	}
+	// a context that is done already stops the program before it starts
+	got = New(Limits{}).Eval(ctx, parse(t, "1"), object.NewEnvironment())
+	if err, ok := got.(*object.Error); !ok || !errors.Is(err, context.Canceled) {
+		t.Errorf("got %s, want the program to be canceled", got.Inspect())
+	}
+}
+
+func TestInterpreterReuse(t *testing.T) {
+	in := New(Limits{MaxSteps: 200})
+	env := object.NewEnvironment()
+	if got := in.Eval(context.Background(), parse(t, forever), env); !errors.Is(got.(*object.Error), ErrStepLimit) {
+		t.Fatalf("got %s, want the step limit", got.Inspect())
+	}
+	// every Eval gets the steps and the depth anew, in the environment the stopped one left
+	if got := in.Eval(context.Background(), parse(t, "let g = fn() { 7 }; g()"), env); got.Inspect() != "7" {
+		t.Errorf("got %s, want 7", got.Inspect())
+	}
+	if _, ok := env.Get("f"); !ok {
+		t.Errorf("f is not bound after the stopped Eval")
+	}
+}
+
+func TestTimeoutLeaksNoGoroutines(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for i := 0; i < 20; i++ {
+		New(Limits{Timeout: time.Millisecond}).Eval(context.Background(), parse(t, forever), object.NewEnvironment())
+	}
+	// the timers of the contexts are stopped when Eval returns, give the runtime a moment anyway
+	time.Sleep(10 * time.Millisecond)
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("%d goroutines before the timeouts, %d after", before, after)
+	}
+}
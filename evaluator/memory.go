@@ -0,0 +1,133 @@
+package evaluator
+
+import (
+	"monkey/ast"
+	"monkey/object"
+)
+
+/** Memory Accounting **/
+// An Interpreter counts the bytes of the runtime objects it makes: integers, arrays, closures,
+// quoted code, environments and the bindings in them. The counts are approximations of what Go
+// allocates for them, good enough to stop a program that would take up all the memory of the host.
+//
+// Values cannot be changed once made, so all that a call can leave behind is its result. When a
+// call returns, the bytes it made are released, but for those of the result. A result that holds
+// a function keeps all of them, as the function may hold on to the environment of the call.
+
+// Approximate sizes in bytes, with the headers and the slack of Go's allocator
+const (
+	integerSize     = 16
+	functionSize    = 48
+	arraySize       = 32 // and an element for each value in the array
+	elementSize     = 16
+	quoteNodeSize   = 64 // for each node of the quoted code
+	environmentSize = 64 // the struct and its empty map
+	bindingSize     = 48 // the map entry with its name, and the room the map grows by
+)
+
+// MemoryStats describe the memory of the objects an Interpreter made, in bytes
+type MemoryStats struct {
+	InUse     int64 // What is still in use, in the environments that outlive the calls to Eval
+	Peak      int64 // The most that was in use at once
+	Allocated int64 // All that was made
+}
+
+// Memory returns the memory statistics of every Eval in has run so far
+func (in *Interpreter) Memory() MemoryStats {
+	return in.memory
+}
+
+// Helper function that counts n bytes as made and in use. Exceeding the limit is reported by the
+// next step, so that the makers of objects need not check.
+func (in *Interpreter) alloc(n int64) {
+	in.memory.InUse += n
+	in.memory.Allocated += n
+	if in.memory.InUse > in.memory.Peak {
+		in.memory.Peak = in.memory.InUse
+	}
+}
+
+// Helper function that counts the bytes of obj, which was just made, and returns it
+func (in *Interpreter) track(obj object.Object) object.Object {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		in.alloc(integerSize)
+	case *object.Function:
+		in.alloc(functionSize)
+	case *object.Array:
+		in.alloc(arraySize + elementSize*int64(len(obj.Elements)))
+	case *object.Quote:
+		in.alloc(quoteNodeSize * countNodes(obj.Node))
+	}
+	return obj
+}
+
+// Helper function that makes an environment enclosed by outer and counts its bytes
+func (in *Interpreter) newEnvironment(outer *object.Environment) *object.Environment {
+	in.alloc(environmentSize)
+	return object.NewEnclosedEnvironment(outer)
+}
+
+// Helper function that binds name to val in env and counts the bytes of the binding
+func (in *Interpreter) set(env *object.Environment, name string, val object.Object) {
+	in.alloc(bindingSize)
+	env.Set(name, val)
+}
+
+// Helper function that releases the bytes made since mark, but for kept of them, or all of them if
+// kept is negative
+func (in *Interpreter) release(mark, kept int64) {
+	if kept < 0 || in.memory.InUse-mark <= kept {
+		return
+	}
+	in.memory.InUse = mark + kept
+}
+
+// Helper function that returns the bytes that obj holds on to, or -1 if it holds a function,
+// which holds on to an environment
+func retained(obj object.Object) int64 {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return integerSize
+	case *object.Function:
+		return -1
+	case *object.Array:
+		size := arraySize + elementSize*int64(len(obj.Elements))
+		for _, el := range obj.Elements {
+			n := retained(el)
+			if n < 0 {
+				return -1
+			}
+			size += n
+		}
+		return size
+	case *object.Quote:
+		return quoteNodeSize * countNodes(obj.Node)
+	}
+	return 0
+}
+
+// Helper function that returns the bytes that env holds on to, besides its enclosing environments
+func retainedEnvironment(env *object.Environment) int64 {
+	size := int64(environmentSize)
+	for _, val := range env.Values() {
+		n := retained(val)
+		if n < 0 {
+			return -1
+		}
+		size += bindingSize + n
+	}
+	return size
+}
+
+// Helper function that returns the number of nodes in the tree of node
+func countNodes(node ast.Node) int64 {
+	var n int64
+	ast.Inspect(node, func(node ast.Node) bool {
+		if node != nil {
+			n++
+		}
+		return true
+	})
+	return n
+}
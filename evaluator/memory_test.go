@@ -0,0 +1,129 @@
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"monkey/object"
+	"testing"
+)
+
+// Helper function that runs input with limits in a fresh Interpreter and returns the result and its memory
+func runWithMemory(t *testing.T, input string, limits Limits) (object.Object, MemoryStats) {
+	t.Helper()
+	in := New(limits)
+	result := in.Eval(context.Background(), parse(t, input), object.NewEnvironment())
+	return result, in.Memory()
+}
+
+const (
+	tailLoop  = "let loop = fn(n) { if (n == 0) { 0 } else { loop(n - 1) } }; loop(%d)"
+	recursion = "let f = fn(n) { if (n == 0) { 0 } else { 1 + f(n - 1) } }; f(%d)"
+	closures  = "let f = fn(n, acc) { if (n == 0) { acc } else { f(n - 1, fn() { acc }) } }; f(%d, 0)"
+)
+
+func TestMemoryStats(t *testing.T) {
+	tests := []struct {
+		input string
+		want  MemoryStats
+	}{
+		{"1 + 2", MemoryStats{InUse: 3 * integerSize, Peak: 3 * integerSize, Allocated: 3 * integerSize}},
+		{"true == false", MemoryStats{}},
+		{"let f = fn(a) { a }", MemoryStats{InUse: functionSize + bindingSize, Peak: functionSize + bindingSize, Allocated: functionSize + bindingSize}},
+		// the environment of the call and the integer bound in it are gone once it returns
+		{"let f = fn(a) { 0 }; f(1)", MemoryStats{
+			InUse:     functionSize + bindingSize + integerSize,
+			Peak:      functionSize + bindingSize + 2*integerSize + environmentSize + bindingSize,
+			Allocated: functionSize + bindingSize + 2*integerSize + environmentSize + bindingSize,
+		}},
+		// but for what the result holds on to
+		{"let f = fn(...a) { a }; let r = f(1)", MemoryStats{
+			InUse: functionSize + 2*bindingSize + arraySize + elementSize + integerSize,
+			// while the call runs, before r is bound
+			Peak:      functionSize + bindingSize + arraySize + elementSize + integerSize + environmentSize + bindingSize,
+			Allocated: functionSize + 2*bindingSize + arraySize + elementSize + integerSize + environmentSize + bindingSize,
+		}},
+		// a function holds on to the environment it was made in
+		{"let adder = fn(a) { fn(b) { a + b } }; let k = adder(1)", MemoryStats{
+			InUse:     2*functionSize + 3*bindingSize + integerSize + environmentSize,
+			Peak:      2*functionSize + 3*bindingSize + integerSize + environmentSize,
+			Allocated: 2*functionSize + 3*bindingSize + integerSize + environmentSize,
+		}},
+	}
+	for _, tt := range tests {
+		result, got := runWithMemory(t, tt.input, Limits{})
+		if isError(result) {
+			t.Errorf("%q: unexpected error %s", tt.input, result.Inspect())
+		}
+		if got != tt.want {
+			t.Errorf("%q: got %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestMemoryOfCalls(t *testing.T) {
+	peak := func(format string, n int) MemoryStats {
+		_, mem := runWithMemory(t, fmt.Sprintf(format, n), Limits{})
+		return mem
+	}
+	// a tail-recursive loop takes up as much memory however long it runs
+	if short, long := peak(tailLoop, 10), peak(tailLoop, 10000); short.Peak != long.Peak || short.InUse != long.InUse {
+		t.Errorf("loop of 10 takes up %+v, loop of 10000 %+v", short, long)
+	}
+	// recursion takes up memory for every call that runs, and gives it back when they return
+	short, long := peak(recursion, 100), peak(recursion, 200)
+	if perCall := (long.Peak - short.Peak) / 100; perCall < environmentSize+bindingSize || long.Peak-short.Peak != 100*perCall {
+		t.Errorf("recursion of 100 peaks at %d bytes, of 200 at %d", short.Peak, long.Peak)
+	}
+	if short.InUse != long.InUse {
+		t.Errorf("recursion of 100 leaves %d bytes in use, of 200 %d", short.InUse, long.InUse)
+	}
+	// closures that are returned hold on to every environment in the chain
+	if short, long := peak(closures, 100), peak(closures, 200); long.InUse <= short.InUse {
+		t.Errorf("a chain of 100 closures leaves %d bytes in use, of 200 %d", short.InUse, long.InUse)
+	}
+}
+
+func TestMemoryLimit(t *testing.T) {
+	tests := []struct {
+		input     string
+		maxMemory int64
+		fails     bool
+	}{
+		{fmt.Sprintf(tailLoop, 100000), 1000, false},
+		{fmt.Sprintf(recursion, 100), 100000, false},
+		{fmt.Sprintf(recursion, 1000), 100000, true},
+		{fmt.Sprintf(closures, 1000), 100000, true},
+		{fmt.Sprintf(closures, 10), 100000, false},
+	}
+	for _, tt := range tests {
+		result, mem := runWithMemory(t, tt.input, Limits{MaxMemory: tt.maxMemory})
+		err, isErr := result.(*object.Error)
+		switch {
+		case !tt.fails && isErr:
+			t.Errorf("%q: unexpected error %s", tt.input, err.Message)
+		case tt.fails && (!isErr || !errors.Is(err, ErrOutOfMemory)):
+			t.Errorf("%q: got %s, want to run out of memory", tt.input, result.Inspect())
+		case tt.fails && err.Message != "out of memory: more than 100000 bytes in use":
+			t.Errorf("%q: wrong message %q", tt.input, err.Message)
+		case !tt.fails && mem.Peak > tt.maxMemory:
+			t.Errorf("%q: peaked at %d bytes, more than the limit", tt.input, mem.Peak)
+		}
+	}
+}
+
+func TestMemoryAcrossEvals(t *testing.T) {
+	in := New(Limits{MaxMemory: 2 * functionSize})
+	env := object.NewEnvironment()
+	if got := in.Eval(context.Background(), parse(t, "let f = fn() { 1 }"), env); isError(got) {
+		t.Fatalf("unexpected error %s", got.Inspect())
+	}
+	// what the first Eval left in the environment still counts
+	got := in.Eval(context.Background(), parse(t, "let g = fn() { 2 }; let h = fn() { 3 }; h()"), env)
+	if err, ok := got.(*object.Error); !ok || !errors.Is(err, ErrOutOfMemory) {
+		t.Errorf("got %s, want to run out of memory", got.Inspect())
+	}
+	if mem := in.Memory(); mem.Peak < 2*functionSize+2*bindingSize {
+		t.Errorf("got peak %d, want at least the two functions", mem.Peak)
+	}
+}
@@ -0,0 +1,94 @@
+package evaluator
+
+import (
+	"monkey/ast"
+	"monkey/object"
+)
+
+/** Bind Pattern **/
+// binds the names of pattern to the matching parts of val in env,
+// it returns an *object.Error when val does not have the shape of the pattern
+func (in *Interpreter) bindPattern(pattern ast.Pattern, val object.Object, env *object.Environment) *object.Error {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		in.set(env, pattern.Value, val)
+		return nil
+	case *ast.ARRAY_Pattern:
+		return in.bindArrayPattern(pattern, val, env)
+	case *ast.HASH_Pattern:
+		// there are no hash values yet, so nothing has the shape of a hash pattern
+		return newError("cannot destructure %s with hash pattern %s", val.Type(), pattern.Node_String())
+	}
+	return newError("unknown pattern %s", pattern.Node_String())
+}
+
+func (in *Interpreter) bindArrayPattern(pattern *ast.ARRAY_Pattern, val object.Object, env *object.Environment) *object.Error {
+	arr, ok := val.(*object.Array)
+	if !ok {
+		return newError("cannot destructure %s with array pattern %s", val.Type(), pattern.Node_String())
+	}
+	want := len(pattern.Elements)
+	switch {
+	case pattern.Rest == nil && len(arr.Elements) != want:
+		return newError("array pattern %s does not match an array of length %d", pattern.Node_String(), len(arr.Elements))
+	case len(arr.Elements) < want:
+		return newError("array pattern %s needs at least %d elements, got %d", pattern.Node_String(), want, len(arr.Elements))
+	}
+	for i, el := range pattern.Elements {
+		if err := in.bindPattern(el, arr.Elements[i], env); err != nil {
+			return err
+		}
+	}
+	if pattern.Rest != nil {
+		rest := make([]object.Object, len(arr.Elements)-want)
+		copy(rest, arr.Elements[want:])
+		in.set(env, pattern.Rest.Value, in.track(&object.Array{Elements: rest}))
+	}
+	return nil
+}
+
+/** Match Pattern **/
+// tells whether val has the shape of a refutable pattern, binding the names of the pattern in env as it goes
+func (in *Interpreter) matchPattern(pattern ast.Pattern, val object.Object, env *object.Environment) bool {
+	switch pattern := pattern.(type) {
+	case *ast.WILDCARD_Pattern:
+		return true
+	case *ast.Identifier:
+		in.set(env, pattern.Value, val)
+		return true
+	case *ast.LITERAL_Pattern:
+		return matchLiteral(in.eval(pattern.Value, env, false), val)
+	case *ast.ARRAY_Pattern:
+		arr, ok := val.(*object.Array)
+		if !ok || len(arr.Elements) < len(pattern.Elements) {
+			return false
+		}
+		if pattern.Rest == nil && len(arr.Elements) != len(pattern.Elements) {
+			return false
+		}
+		for i, el := range pattern.Elements {
+			if !in.matchPattern(el, arr.Elements[i], env) {
+				return false
+			}
+		}
+		if pattern.Rest != nil {
+			rest := make([]object.Object, len(arr.Elements)-len(pattern.Elements))
+			copy(rest, arr.Elements[len(pattern.Elements):])
+			in.set(env, pattern.Rest.Value, in.track(&object.Array{Elements: rest}))
+		}
+		return true
+	}
+	// there are no hash values yet, so no value matches a hash pattern
+	return false
+}
+
+func matchLiteral(literal, val object.Object) bool {
+	switch literal := literal.(type) {
+	case *object.Integer:
+		i, ok := val.(*object.Integer)
+		return ok && i.Value == literal.Value
+	case *object.Boolean:
+		return literal == val
+	}
+	return false
+}
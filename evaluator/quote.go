@@ -0,0 +1,94 @@
+package evaluator
+
+import (
+	"monkey/ast"
+	"monkey/object"
+	"monkey/token"
+	"strconv"
+)
+
+/** Quote and Unquote **/
+// quote(expr) is not a call: it evaluates to the code expr itself, except that every unquote(x)
+// in it is replaced by the code for the value of x. Macros are built from them, see package macro.
+
+// Helper function that tells whether a call is to quote or unquote, which work on the code of
+// their argument rather than on its value
+func isQuoteForm(ce *ast.CALL_Expression) bool {
+	id, ok := ce.Function.(*ast.Identifier)
+	return ok && (id.Value == "quote" || id.Value == "unquote")
+}
+
+func (in *Interpreter) evalQuoteForm(ce *ast.CALL_Expression, env *object.Environment) object.Object {
+	name := ce.Function.(*ast.Identifier).Value
+	if err := checkQuoteForm(name, ce); err != nil {
+		return err
+	}
+	if name == "unquote" {
+		return newError("unquote outside of quote")
+	}
+	return in.quote(ast.Copy(ce.Arguments[0]), env)
+}
+
+// Helper function that returns an error unless quote or unquote is given a single positional argument
+func checkQuoteForm(name string, ce *ast.CALL_Expression) *object.Error {
+	if len(ce.Arguments) != 1 {
+		return newError("%s takes 1 argument, got %d", name, len(ce.Arguments))
+	}
+	if kw, ok := ce.Arguments[0].(*ast.KEYWORD_Argument); ok {
+		return newError("%s takes no keyword argument, got %s", name, kw.Name.Value)
+	}
+	return nil
+}
+
+// Helper function that replaces the unquote calls in code, which it owns, by the code of their values
+func (in *Interpreter) quote(code ast.Node, env *object.Environment) object.Object {
+	var failed object.Object
+	code = ast.Modify(code, func(node ast.Node) ast.Node {
+		ce, ok := node.(*ast.CALL_Expression)
+		if !ok || failed != nil {
+			return node
+		}
+		if id, ok := ce.Function.(*ast.Identifier); !ok || id.Value != "unquote" {
+			return node
+		}
+		if err := checkQuoteForm("unquote", ce); err != nil {
+			failed = err
+			return node
+		}
+		val := in.eval(ce.Arguments[0], env, false)
+		if isError(val) {
+			failed = val
+			return node
+		}
+		replacement, err := objectToCode(val, ce.Token)
+		if err != nil {
+			failed = err
+			return node
+		}
+		return replacement
+	})
+	if failed != nil {
+		return failed
+	}
+	return in.track(&object.Quote{Node: code})
+}
+
+// Helper function that returns the code for a value, positioned at the unquote it replaces.
+// Only integers, booleans and quoted code can be written as code.
+func objectToCode(val object.Object, at token.Token) (ast.Node, *object.Error) {
+	switch val := val.(type) {
+	case *object.Integer:
+		// a negative value keeps its sign in the token, as the optimizer's folded literals do
+		return &ast.INTEGER_Literal{Token: token.Token{Type: token.INT, Literal: strconv.FormatInt(val.Value, 10), Pos: at.Pos}, Value: val.Value}, nil
+	case *object.Boolean:
+		tok := token.Token{Type: token.FALSE, Literal: "false", Pos: at.Pos}
+		if val.Value {
+			tok.Type, tok.Literal = token.TRUE, "true"
+		}
+		return &ast.Boolean{Token: tok, Value: val.Value}, nil
+	case *object.Quote:
+		// each unquote of the same quote gets code of its own
+		return ast.Copy(val.Node), nil
+	}
+	return nil, newError("cannot unquote %s: only integers, booleans and quoted code can be turned into code", val.Inspect())
+}
@@ -0,0 +1,162 @@
+package format
+
+import (
+	"monkey/ast"
+	"monkey/cst"
+	"strings"
+)
+
+// A comment is a `//` comment along with whether an empty line separated it from what came before
+type comment struct {
+	text        string
+	blankBefore bool
+}
+
+// The comments around a statement
+type statementComments struct {
+	leading     []comment // Printed on their own lines before the statement
+	blankBefore bool      // Whether an empty line separated the statement from its leading comments or the previous statement
+	trailing    string    // Printed at the end of the statement's last line
+}
+
+// The comments of a block that belong to none of its statements
+type blockComments struct {
+	head []comment // Right after the '{'
+	tail []comment // Right before the '}'
+}
+
+// The comments of a file, keyed by the node they are attached to
+type comments struct {
+	statements map[ast.Statement]*statementComments
+	blocks     map[*ast.BlockStatement]*blockComments
+	tail       []comment // After the last statement of the file
+}
+
+// Helper function that attaches every comment in the file to a statement or a block.
+// Comments in the middle of a statement, e.g. between the operands of an infix expression,
+// are moved before the statement.
+func collectComments(file *cst.File) *comments {
+	c := &comments{statements: map[ast.Statement]*statementComments{}, blocks: map[*ast.BlockStatement]*blockComments{}}
+	for _, child := range file.Children {
+		switch ch := child.(type) {
+		case *cst.Node:
+			c.statement(ch)
+		case *cst.Token:
+			// the EOF token, preceded by the comments at the end of the file
+			c.tail = append(c.tail, leadingComments(ch.Leading)...)
+		}
+	}
+	return c
+}
+
+func (c *comments) statement(node *cst.Node) {
+	stmt, ok := node.AST.(ast.Statement)
+	if !ok {
+		return
+	}
+	tokens := node.Tokens()
+	first, last := tokens[0], tokens[len(tokens)-1]
+	info := &statementComments{}
+	info.leading = leadingComments(first.Leading)
+	info.blankBefore = endsWithBlankLine(first.Leading)
+	info.trailing = trailingComment(last.Trailing)
+	inner := []comment{}
+
+	var visit func(node *cst.Node)
+	visit = func(node *cst.Node) {
+		for _, child := range node.Children {
+			switch ch := child.(type) {
+			case *cst.Token:
+				if ch != first {
+					inner = append(inner, leadingComments(ch.Leading)...)
+				}
+				if ch != last {
+					if text := trailingComment(ch.Trailing); text != "" {
+						inner = append(inner, comment{text: text})
+					}
+				}
+			case *cst.Node:
+				switch n := ch.AST.(type) {
+				case *ast.BlockStatement:
+					c.block(ch, n, last)
+				case ast.Statement:
+					c.statement(ch)
+				default:
+					visit(ch)
+				}
+			}
+		}
+	}
+	visit(node)
+	for i := range inner {
+		inner[i].blankBefore = false
+	}
+	info.leading = append(info.leading, inner...)
+	c.statements[stmt] = info
+}
+
+// last is the last token of the enclosing statement, whose trailing comment belongs to the statement
+func (c *comments) block(node *cst.Node, block *ast.BlockStatement, last *cst.Token) {
+	info := &blockComments{}
+	for _, child := range node.Children {
+		switch ch := child.(type) {
+		case *cst.Node:
+			c.statement(ch)
+		case *cst.Token:
+			if ch.Type == "{" {
+				info.head = append(info.head, leadingComments(ch.Leading)...)
+				if text := trailingComment(ch.Trailing); text != "" {
+					info.head = append(info.head, comment{text: text})
+				}
+				continue
+			}
+			info.tail = append(info.tail, leadingComments(ch.Leading)...)
+			if text := trailingComment(ch.Trailing); text != "" && ch != last {
+				info.tail = append(info.tail, comment{text: text})
+			}
+		}
+	}
+	c.blocks[block] = info
+}
+
+// Helper function that reads the comments out of the trivia before a token, one per line.
+// The trivia starts after the end of the previous token's line, so any empty line in it is a blank line.
+func leadingComments(trivia string) []comment {
+	result := []comment{}
+	lines := strings.Split(trivia, "\n")
+	blank := false
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "//"):
+			result = append(result, comment{text: line, blankBefore: blank})
+			blank = false
+		case line == "" && i < len(lines)-1:
+			blank = true
+		}
+	}
+	return result
+}
+
+// Helper function that reports whether an empty line separates the token from the last comment
+// of its leading trivia, or from the previous token if there are no comments
+func endsWithBlankLine(trivia string) bool {
+	if i := strings.LastIndex(trivia, "//"); i >= 0 {
+		trivia = trivia[i:]
+		// skip the comment's own line
+		if j := strings.IndexByte(trivia, '\n'); j >= 0 {
+			trivia = trivia[j+1:]
+		} else {
+			return false
+		}
+	}
+	return strings.Contains(trivia, "\n")
+}
+
+// Helper function that returns the comment in the trivia after a token, which runs at most to the end of its line
+func trailingComment(trivia string) string {
+	if i := strings.Index(trivia, "//"); i >= 0 {
+		return strings.TrimSpace(trivia[i:])
+	}
+	return ""
+}
@@ -0,0 +1,457 @@
+// Package format prints Monkey programs in their canonical form.
+//
+// The output is valid Monkey that parses back to the same program: one statement per line,
+// tab indentation, single spaces around infix operators and after commas, and only the
+// parentheses that precedence requires. Comments are kept.
+//
+// A call with two or more arguments that would run past column 80, counting a tab as four
+// columns, gets one argument per line. Nothing else is broken, so a long chain of operators
+// stays on one line however long it is.
+package format
+
+import (
+	"monkey/ast"
+	"monkey/cst"
+	"monkey/parser"
+	"monkey/token"
+	"strconv"
+	"strings"
+)
+
+// Calls whose arguments would run past this column are broken one argument per line
+const lineWidth = 80
+
+// tabWidth is the width of one level of indentation when measuring lines
+const tabWidth = 4
+
+// Source formats a program. The returned error is a parser.ErrorList if src does not parse.
+func Source(src []byte) ([]byte, error) {
+	file, err := cst.Parse(string(src))
+	if err != nil {
+		return nil, err
+	}
+	p := &printer{comments: collectComments(file)}
+	p.collectSugar(&file.Node)
+	return []byte(p.program(file.AST.(*ast.Program))), nil
+}
+
+// Node formats a single node, without comments, as it would appear at the start of an unindented line
+func Node(node ast.Node) string {
+	p := &printer{}
+	switch n := node.(type) {
+	case *ast.Program:
+		return p.program(n)
+	case *ast.BlockStatement:
+		return p.block(n, 0)
+	case ast.Statement:
+		text, open := p.statement(n, 0)
+		if open {
+			return text
+		}
+		return text + ";"
+	case ast.Expression:
+		return p.expression(n, 0, 0)
+	case ast.Pattern:
+		return p.pattern(n)
+	case ast.TypeExpr:
+		return p.typeExpr(n)
+	case *ast.Parameter:
+		return p.parameter(n, 0, 0)
+	case *ast.HASH_PatternEntry:
+		return p.hashPatternEntry(n)
+	case *ast.MATCH_Arm:
+		return p.matchArm(n, 0, 0)
+	}
+	return node.Node_String()
+}
+
+type printer struct {
+	comments *comments                     // nil when printing a lone node
+	pipes    map[*ast.CALL_Expression]bool // Calls written as `arg |> f(...)` in the source
+	arrows   map[*ast.FunctionLiteral]bool // Functions written as `(a, b) => ...` in the source
+}
+
+// Helper function that finds the pipes and arrow functions the parser desugared, so that
+// they are printed the way they were written
+func (p *printer) collectSugar(node *cst.Node) {
+	p.pipes = map[*ast.CALL_Expression]bool{}
+	p.arrows = map[*ast.FunctionLiteral]bool{}
+	var visit func(node *cst.Node)
+	visit = func(node *cst.Node) {
+		for _, child := range node.Children {
+			switch ch := child.(type) {
+			case *cst.Token:
+				switch n := node.AST.(type) {
+				case *ast.CALL_Expression:
+					p.pipes[n] = p.pipes[n] || ch.Type == token.PIPE
+				case *ast.FunctionLiteral:
+					p.arrows[n] = p.arrows[n] || ch.Type == token.ARROW
+				}
+			case *cst.Node:
+				visit(ch)
+			}
+		}
+	}
+	visit(node)
+}
+
+func indent(depth int) string {
+	return strings.Repeat("\t", depth)
+}
+
+/** Statements **/
+
+func (p *printer) program(program *ast.Program) string {
+	var out strings.Builder
+	p.statements(&out, program.Statements, 0)
+	if p.comments != nil {
+		p.commentLines(&out, p.comments.tail, 0, len(program.Statements) > 0)
+	}
+	return out.String()
+}
+
+// Helper function that writes a list of statements, one per line, each followed by a newline
+func (p *printer) statements(out *strings.Builder, list []ast.Statement, depth int) {
+	texts := make([]string, len(list))
+	open := make([]bool, len(list))
+	for i, stmt := range list {
+		texts[i], open[i] = p.statement(stmt, depth)
+	}
+	for i, stmt := range list {
+		info := p.statementComments(stmt)
+		p.commentLines(out, info.leading, depth, i > 0)
+		if info.blankBefore && (i > 0 || len(info.leading) > 0) {
+			out.WriteString("\n")
+		}
+		out.WriteString(indent(depth))
+		out.WriteString(texts[i])
+		// An if or match statement needs no ';' unless the next statement would continue it, e.g. as `(...)` call arguments
+		if !open[i] || i+1 < len(list) && (strings.HasPrefix(texts[i+1], "(") || strings.HasPrefix(texts[i+1], "-")) {
+			out.WriteString(";")
+		}
+		if info.trailing != "" {
+			out.WriteString(" " + info.trailing)
+		}
+		out.WriteString("\n")
+	}
+}
+
+func (p *printer) statementComments(stmt ast.Statement) *statementComments {
+	if p.comments != nil {
+		if info, ok := p.comments.statements[stmt]; ok {
+			return info
+		}
+	}
+	return &statementComments{}
+}
+
+// Helper function that writes comments on their own lines. An empty line is kept before a
+// comment that had one, except before the very first line of a file or a block.
+func (p *printer) commentLines(out *strings.Builder, list []comment, depth int, afterContent bool) {
+	for i, c := range list {
+		if c.blankBefore && (afterContent || i > 0) {
+			out.WriteString("\n")
+		}
+		out.WriteString(indent(depth) + c.text + "\n")
+	}
+}
+
+// statement returns the text of a statement without its final ';', and whether the statement
+// is an if or match expression, which ends in '}' and does not need one
+func (p *printer) statement(stmt ast.Statement, depth int) (string, bool) {
+	switch s := stmt.(type) {
+	case *ast.LET_Statement:
+		text := "let " + p.pattern(s.Name)
+		if s.Type != nil {
+			text += ": " + p.typeExpr(s.Type)
+		}
+		text += " = "
+		return text + p.expression(s.Value, depth, depth*tabWidth+len(text)), false
+	case *ast.RETURN_Statement:
+		return "return " + p.expression(s.ReturnValue, depth, depth*tabWidth+len("return ")), false
+	case *ast.EXPRESSION_Statement:
+		switch s.Expression.(type) {
+		case *ast.IF_Expression, *ast.MATCH_Expression:
+			return p.expression(s.Expression, depth, depth*tabWidth), true
+		}
+		return p.expression(s.Expression, depth, depth*tabWidth), false
+	case *ast.BlockStatement:
+		return p.block(s, depth), true
+	}
+	return stmt.Node_String(), false
+}
+
+// Helper function that prints a block starting at the current position of a line indented by depth
+func (p *printer) block(block *ast.BlockStatement, depth int) string {
+	info := &blockComments{}
+	if p.comments != nil && p.comments.blocks[block] != nil {
+		info = p.comments.blocks[block]
+	}
+	if len(block.Statemens) == 0 && len(info.head) == 0 && len(info.tail) == 0 {
+		return "{}"
+	}
+	var out strings.Builder
+	out.WriteString("{\n")
+	p.commentLines(&out, info.head, depth+1, false)
+	p.statements(&out, block.Statemens, depth+1)
+	p.commentLines(&out, info.tail, depth+1, len(info.head)+len(block.Statemens) > 0)
+	out.WriteString(indent(depth) + "}")
+	return out.String()
+}
+
+/** Expressions **/
+
+// Binding power of expressions that never need parentheses
+const atom = parser.CALL + 1
+
+// Helper function that returns how tightly an expression binds, see parser.Precedence
+func (p *printer) precedenceOf(expr ast.Expression) int {
+	switch e := expr.(type) {
+	case *ast.INFIX_Expression:
+		return parser.Precedence(e.Token.Type)
+	case *ast.PREFIX_Expression:
+		return parser.PREFIX
+	case *ast.CALL_Expression:
+		if p.pipes[e] {
+			return parser.PIPE
+		}
+		return parser.CALL
+	case *ast.FunctionLiteral:
+		// the body of an arrow function extends as far to the right as it can
+		if p.arrows[e] {
+			return parser.LOWEST
+		}
+	}
+	return atom
+}
+
+// Helper function that prints an operand, in parentheses if it binds looser than min
+func (p *printer) operand(expr ast.Expression, min int, depth int, col int) string {
+	if p.precedenceOf(expr) < min {
+		return "(" + p.expression(expr, depth, col+1) + ")"
+	}
+	return p.expression(expr, depth, col)
+}
+
+// Helper function that returns the column at which text ends when it starts at col, counting
+// a tab of indentation as tabWidth columns
+func endColumn(col int, text string) int {
+	i := strings.LastIndex(text, "\n")
+	if i < 0 {
+		return col + len(text)
+	}
+	line := text[i+1:]
+	tabs := len(line) - len(strings.TrimLeft(line, "\t"))
+	return tabs*tabWidth + len(line) - tabs
+}
+
+// expression prints an expression that starts at column col of a line indented by depth
+func (p *printer) expression(expr ast.Expression, depth int, col int) string {
+	switch e := expr.(type) {
+	case *ast.Identifier:
+		return e.Value
+	case *ast.INTEGER_Literal:
+		if e.Token.Literal != "" {
+			return e.Token.Literal
+		}
+		return strconv.FormatInt(e.Value, 10)
+	case *ast.Boolean:
+		return strconv.FormatBool(e.Value)
+	case *ast.PREFIX_Expression:
+		right := p.operand(e.Right, parser.PREFIX, depth, col+len(e.Token.Literal))
+		// `-(-x)` must not become `--x`
+		if strings.HasPrefix(right, e.Token.Literal) {
+			right = "(" + right + ")"
+		}
+		return e.Token.Literal + right
+	case *ast.INFIX_Expression:
+		prec := parser.Precedence(e.Token.Type)
+		text := p.operand(e.Left, prec, depth, col) + " " + e.Token.Literal + " "
+		return text + p.operand(e.Right, prec+1, depth, endColumn(col, text))
+	case *ast.IF_Expression:
+		text := "if (" + p.expression(e.Condition, depth, col+len("if (")) + ") " + p.block(e.Consequence, depth)
+		if e.Alternative != nil {
+			text += " else " + p.block(e.Alternative, depth)
+		}
+		return text
+	case *ast.FunctionLiteral:
+		if p.arrows[e] {
+			return p.arrow(e, depth, col)
+		}
+		text := "fn("
+		for i, param := range e.Parameters {
+			if i > 0 {
+				text += ", "
+			}
+			text += p.parameter(param, depth, endColumn(col, text))
+		}
+		text += ")"
+		if e.ReturnType != nil {
+			text += ": " + p.typeExpr(e.ReturnType)
+		}
+		return text + " " + p.block(e.Body, depth)
+	case *ast.MacroLiteral:
+		params := []string{}
+		for _, param := range e.Parameters {
+			params = append(params, param.Value)
+		}
+		return "macro(" + strings.Join(params, ", ") + ") " + p.block(e.Body, depth)
+	case *ast.CALL_Expression:
+		return p.call(e, depth, col)
+	case *ast.KEYWORD_Argument:
+		return e.Name.Value + ": " + p.expression(e.Value, depth, col+len(e.Name.Value)+len(": "))
+	case *ast.MATCH_Expression:
+		subject := p.expression(e.Subject, depth, col+len("match ("))
+		if len(e.Arms) == 0 {
+			return "match (" + subject + ") {}"
+		}
+		var out strings.Builder
+		out.WriteString("match (" + subject + ") {\n")
+		for _, arm := range e.Arms {
+			out.WriteString(indent(depth+1) + p.matchArm(arm, depth+1, (depth+1)*tabWidth) + ",\n")
+		}
+		out.WriteString(indent(depth) + "}")
+		return out.String()
+	}
+	return expr.Node_String()
+}
+
+// Helper function that prints a call on one line, or with one argument per line if it is too long
+func (p *printer) call(call *ast.CALL_Expression, depth int, col int) string {
+	arguments := call.Arguments
+	if p.pipes[call] && len(arguments) > 0 {
+		piped := p.operand(arguments[0], parser.PIPE, depth, col) + " |> "
+		col = endColumn(col, piped)
+		// `x |> f` has no parentheses of its own; the parser gives the call a token without a position
+		if len(arguments) == 1 && call.Token.Pos.Line == 0 {
+			return piped + p.operand(call.Function, parser.PIPE+1, depth, col)
+		}
+		return piped + p.arguments(p.operand(call.Function, parser.CALL, depth, col), arguments[1:], depth, col)
+	}
+	return p.arguments(p.operand(call.Function, parser.CALL, depth, col), arguments, depth, col)
+}
+
+// Helper function that prints the parenthesized argument list of a call after its function,
+// which starts at column col
+func (p *printer) arguments(function string, arguments []ast.Expression, depth int, col int) string {
+	args := []string{}
+	multiline := false
+	text := function + "("
+	for i, arg := range arguments {
+		if i > 0 {
+			text += ", "
+		}
+		arg := p.expression(arg, depth, endColumn(col, text))
+		multiline = multiline || strings.Contains(arg, "\n")
+		args = append(args, arg)
+		text += arg
+	}
+	text += ")"
+	if multiline || len(args) < 2 || endColumn(col, text) <= lineWidth {
+		return text
+	}
+	for i, arg := range arguments {
+		args[i] = indent(depth+1) + p.expression(arg, depth+1, (depth+1)*tabWidth)
+	}
+	return function + "(\n" + strings.Join(args, ",\n") + "\n" + indent(depth) + ")"
+}
+
+// Helper function that prints an arrow function; its parameters are all plain names
+func (p *printer) arrow(fn *ast.FunctionLiteral, depth int, col int) string {
+	params := []string{}
+	for _, param := range fn.Parameters {
+		params = append(params, p.parameter(param, depth, col))
+	}
+	text := "(" + strings.Join(params, ", ") + ")"
+	if len(params) == 1 {
+		text = params[0]
+	}
+	// an expression body is wrapped in a block that has no '{' of its own
+	if fn.Body.Token.Type != token.LBRACE {
+		if stmt, ok := fn.Body.Statemens[0].(*ast.EXPRESSION_Statement); ok && len(fn.Body.Statemens) == 1 {
+			return text + " => " + p.expression(stmt.Expression, depth, endColumn(col, text+" => "))
+		}
+	}
+	return text + " => " + p.block(fn.Body, depth)
+}
+
+func (p *printer) parameter(param *ast.Parameter, depth int, col int) string {
+	text := p.pattern(param.Name)
+	if param.Variadic {
+		text = "..." + text
+	}
+	if param.Type != nil {
+		text += ": " + p.typeExpr(param.Type)
+	}
+	if param.Default != nil {
+		text += " = "
+		text += p.expression(param.Default, depth, endColumn(col, text))
+	}
+	return text
+}
+
+func (p *printer) matchArm(arm *ast.MATCH_Arm, depth int, col int) string {
+	text := p.pattern(arm.Pattern)
+	if arm.Guard != nil {
+		text += " if "
+		text += p.expression(arm.Guard, depth, endColumn(col, text))
+	}
+	text += " => "
+	return text + p.expression(arm.Body, depth, endColumn(col, text))
+}
+
+/** Patterns **/
+
+func (p *printer) pattern(pattern ast.Pattern) string {
+	switch pt := pattern.(type) {
+	case *ast.Identifier:
+		return pt.Value
+	case *ast.WILDCARD_Pattern:
+		return "_"
+	case *ast.LITERAL_Pattern:
+		return p.expression(pt.Value, 0, 0)
+	case *ast.ARRAY_Pattern:
+		elements := []string{}
+		for _, el := range pt.Elements {
+			elements = append(elements, p.pattern(el))
+		}
+		if pt.Rest != nil {
+			elements = append(elements, "..."+pt.Rest.Value)
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case *ast.HASH_Pattern:
+		entries := []string{}
+		for _, en := range pt.Entries {
+			entries = append(entries, p.hashPatternEntry(en))
+		}
+		return "{" + strings.Join(entries, ", ") + "}"
+	}
+	return pattern.Node_String()
+}
+
+func (p *printer) hashPatternEntry(entry *ast.HASH_PatternEntry) string {
+	if id, ok := entry.Value.(*ast.Identifier); ok && id.Value == entry.Key.Value {
+		return entry.Key.Value
+	}
+	return entry.Key.Value + ": " + p.pattern(entry.Value)
+}
+
+/** Types **/
+
+func (p *printer) typeExpr(typ ast.TypeExpr) string {
+	switch t := typ.(type) {
+	case *ast.TYPE_Name:
+		return t.Value
+	case *ast.ARRAY_Type:
+		return "[" + p.typeExpr(t.Element) + "]"
+	case *ast.HASH_Type:
+		return "{" + p.typeExpr(t.Key) + ": " + p.typeExpr(t.Value) + "}"
+	case *ast.FUNCTION_Type:
+		params := []string{}
+		for _, param := range t.Parameters {
+			params = append(params, p.typeExpr(param))
+		}
+		return "fn(" + strings.Join(params, ", ") + "): " + p.typeExpr(t.Return)
+	}
+	return typ.Node_String()
+}
@@ -0,0 +1,65 @@
+package format
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"let r=xs|>map(f)|>g;", "let r = xs |> map(f) |> g;\n"},
+		{"let add=(a,b)=>a+b; let inc = x=>x+1;", "let add = (a, b) => a + b;\nlet inc = x => x + 1;\n"},
+		{
+			"let v = match (x) { 0 => true, [a, _] if a > 1 => false, {k} => k };",
+			"let v = match (x) {\n\t0 => true,\n\t[a, _] if a > 1 => false,\n\t{k} => k,\n};\n",
+		},
+		{"let y = 1;   // one\n// lead\nlet z = y*(2+3);", "let y = 1; // one\n// lead\nlet z = y * (2 + 3);\n"},
+		{"let x = ((1 + 2)) * -(-3);", "let x = (1 + 2) * -(-3);\n"},
+		{
+			"let f:fn( int,[bool] ):{string:int}=fn(a:int=1,...r:[ int ]):int{a};",
+			"let f: fn(int, [bool]): {string: int} = fn(a: int = 1, ...r: [int]): int {\n\ta;\n};\n",
+		},
+		{
+			"let veryLongFunctionName = fn(alphaParameter, betaParameter, gammaParameter) { alphaParameter + betaParameter * gammaParameter + someOtherIdentifier(alphaParameter, betaParameter) };",
+			"let veryLongFunctionName = fn(alphaParameter, betaParameter, gammaParameter) {\n" +
+				"\talphaParameter + betaParameter * gammaParameter + someOtherIdentifier(\n" +
+				"\t\talphaParameter,\n" +
+				"\t\tbetaParameter\n" +
+				"\t);\n" +
+				"};\n",
+		},
+	}
+	for _, tt := range tests {
+		got, err := Source([]byte(tt.src))
+		if err != nil {
+			t.Fatalf("formatting %q: %v", tt.src, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("formatting %q:\ngot:\n%s\nwant:\n%s", tt.src, got, tt.want)
+		}
+		again, err := Source(got)
+		if err != nil {
+			t.Fatalf("formatting the output of %q: %v", tt.src, err)
+		}
+		if string(again) != string(got) {
+			t.Errorf("formatting %q is not idempotent:\nonce:\n%s\ntwice:\n%s", tt.src, got, again)
+		}
+	}
+}
+
+// Every line of the output fits in lineWidth columns when the calls on it can be broken
+func TestLineWidth(t *testing.T) {
+	src := "let f = fn(a) { let result = someFunction(alphaArgument, betaArgument, gammaArgument, deltaArgument); result };"
+	got, err := Source([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(string(got), "\n") {
+		if width := endColumn(0, strings.ReplaceAll(line, "\t", "    ")); width > lineWidth {
+			t.Errorf("line is %d columns wide: %q", width, line)
+		}
+	}
+}
@@ -7,11 +7,13 @@ type Lexer struct {
 	input string // The input that is being scanned
 	index int    // The index of the next character to be read
 	char  byte   // The current character that is being read == input[index - 1]
+	line  int    // The line of the current character
+	col   int    // The column of the current character
 }
 
 // Helper function to create a new Lexer
 func New(input string) *Lexer {
-	lx := &Lexer{input: input}
+	lx := &Lexer{input: input, line: 1}
 	lx.readNextChar()
 	return lx
 }
@@ -27,6 +29,13 @@ func (lx *Lexer) peekChar() byte {
 
 // Helper function to read the next character and advance the pointers
 func (lx *Lexer) readNextChar() {
+	if lx.char == '\n' {
+		lx.line++
+		lx.col = 0
+	}
+	if lx.index <= len(lx.input) {
+		lx.col++
+	}
 	// If the index to be read is beyond the input then assign lx.char = '\0'
 	if lx.index >= len(lx.input) {
 		lx.char = 0
@@ -44,12 +53,15 @@ var KEYWORDS = map[string]token.TokenType{
 	"if":     token.IF,
 	"return": token.RETURN,
 	"let":    token.LET,
+	"match":  token.MATCH,
+	"macro":  token.MACRO,
 }
 
 // GetNextToken returns the next token
 func (lx *Lexer) GetNextToken() token.Token {
 	lx.skipWhiteSpaces()
 	var tok token.Token
+	pos := lx.position()
 	switch lx.char {
 	case 0:
 		tok = token.Token{Literal: "", Type: token.EOF}
@@ -57,6 +69,9 @@ func (lx *Lexer) GetNextToken() token.Token {
 		if lx.peekChar() == '=' {
 			lx.readNextChar()
 			tok = token.Token{Literal: "==", Type: token.EQ}
+		} else if lx.peekChar() == '>' {
+			lx.readNextChar()
+			tok = token.Token{Literal: "=>", Type: token.ARROW}
 		} else {
 			tok = lx.makeToken(token.ASSIGN)
 		}
@@ -68,12 +83,26 @@ func (lx *Lexer) GetNextToken() token.Token {
 		tok = lx.makeToken(token.RPAREN)
 	case ',':
 		tok = lx.makeToken(token.COMMA)
+	case ':':
+		tok = lx.makeToken(token.COLON)
+	case '.':
+		if lx.peekChar() == '.' && lx.index+1 < len(lx.input) && lx.input[lx.index+1] == '.' {
+			lx.readNextChar()
+			lx.readNextChar()
+			tok = token.Token{Literal: "...", Type: token.ELLIPSIS}
+		} else {
+			tok = lx.makeToken(token.ILLEGAL)
+		}
 	case '+':
 		tok = lx.makeToken(token.PLUS)
 	case '{':
 		tok = lx.makeToken(token.LBRACE)
 	case '}':
 		tok = lx.makeToken(token.RBRACE)
+	case '[':
+		tok = lx.makeToken(token.LBRACKET)
+	case ']':
+		tok = lx.makeToken(token.RBRACKET)
 	case '-':
 		tok = lx.makeToken(token.MINUS)
 	case '!':
@@ -83,6 +112,13 @@ func (lx *Lexer) GetNextToken() token.Token {
 		} else {
 			tok = lx.makeToken(token.BANG)
 		}
+	case '|':
+		if lx.peekChar() == '>' {
+			lx.readNextChar()
+			tok = token.Token{Literal: "|>", Type: token.PIPE}
+		} else {
+			tok = lx.makeToken(token.ILLEGAL)
+		}
 	case '*':
 		tok = lx.makeToken(token.ASTERISK)
 	case '/':
@@ -96,26 +132,32 @@ func (lx *Lexer) GetNextToken() token.Token {
 			lit := lx.readIdentifier()
 			_type, ok := KEYWORDS[lit]
 			if ok {
-				return token.Token{Literal: lit, Type: _type}
+				return token.Token{Literal: lit, Type: _type, Pos: pos}
 			}
-			return token.Token{Literal: lit, Type: token.IDENTIFIER}
+			return token.Token{Literal: lit, Type: token.IDENTIFIER, Pos: pos}
 		} else if lx.isDigit() {
-			return token.Token{Literal: lx.readNumber(), Type: token.INT}
+			return token.Token{Literal: lx.readNumber(), Type: token.INT, Pos: pos}
 		}
-		tok = token.Token{Literal: "", Type: token.ILLEGAL}
+		tok = lx.makeToken(token.ILLEGAL)
 	}
 	lx.readNextChar()
+	tok.Pos = pos
 	return tok
 }
 
+// Helper function that returns the position of the current character
+func (lx *Lexer) position() token.Position {
+	return token.Position{Offset: lx.index - 1, Line: lx.line, Column: lx.col}
+}
+
 // Helper function to make a token
 func (lx *Lexer) makeToken(tType token.TokenType) token.Token {
 	return token.Token{Literal: string(lx.char), Type: tType}
 }
 
-// Helper function to check if lx.char is a string
+// Helper function to check if lx.char is a letter or an underscore
 func (lx *Lexer) isLetter() bool {
-	return lx.char >= 'a' && lx.char <= 'z' || lx.char >= 'A' && lx.char <= 'Z'
+	return lx.char >= 'a' && lx.char <= 'z' || lx.char >= 'A' && lx.char <= 'Z' || lx.char == '_'
 }
 
 // Helper function to read Identifiers
@@ -141,9 +183,18 @@ func (lx *Lexer) readNumber() string {
 	return lx.input[mark : lx.index-1]
 }
 
-// Function to skip white spaces
+// Function to skip white spaces and `//` comments
 func (lx *Lexer) skipWhiteSpaces() {
-	for lx.char == '\t' || lx.char == '\r' || lx.char == '\n' || lx.char == ' ' {
-		lx.readNextChar()
+	for {
+		switch {
+		case lx.char == '\t' || lx.char == '\r' || lx.char == '\n' || lx.char == ' ':
+			lx.readNextChar()
+		case lx.char == '/' && lx.peekChar() == '/':
+			for lx.char != '\n' && lx.char != 0 {
+				lx.readNextChar()
+			}
+		default:
+			return
+		}
 	}
 }
@@ -0,0 +1,32 @@
+package lexer
+
+import (
+	"monkey/token"
+	"testing"
+)
+
+func TestComments(t *testing.T) {
+	input := "// header\nlet x = 1; // one\n//\n  x / 2 // two"
+	want := []struct {
+		typ token.TokenType
+		lit string
+		pos string
+	}{
+		{token.LET, "let", "2:1"},
+		{token.IDENTIFIER, "x", "2:5"},
+		{token.ASSIGN, "=", "2:7"},
+		{token.INT, "1", "2:9"},
+		{token.SEMICOLON, ";", "2:10"},
+		{token.IDENTIFIER, "x", "4:3"},
+		{token.SLASH, "/", "4:5"},
+		{token.INT, "2", "4:7"},
+		{token.EOF, "", "4:15"},
+	}
+	lx := New(input)
+	for i, tt := range want {
+		tok := lx.GetNextToken()
+		if tok.Type != tt.typ || tok.Literal != tt.lit || tok.Pos.String() != tt.pos {
+			t.Errorf("token %d: got %s %q at %s, want %s %q at %s", i, tok.Type, tok.Literal, tok.Pos, tt.typ, tt.lit, tt.pos)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+// Package macro expands the macros of a program, after it is parsed and before it runs.
+//
+// A macro is defined by a let at the top level of the program and is removed from it:
+//
+//	let unless = macro(cond, yes, no) {
+//		quote(if (!(unquote(cond))) { unquote(yes) } else { unquote(no) })
+//	};
+//
+// Every call `unless(x > 1, a, b)`, before or after the definition, is replaced by the code the
+// macro returns. The body is evaluated with each parameter bound to the code of its argument,
+// unevaluated, as quote would give it, and must return quoted code. Within quote(...), an
+// unquote(x) is replaced by the code for the value of x: the code of a parameter, or the literal
+// for an integer or a boolean the body computed, e.g. `unquote(2 * 8)` for 16.
+//
+// Expansion is hygienic: the names that the quoted code binds, with let, as parameters or in
+// match arms, are renamed to names used nowhere else in the program, so that they can neither
+// capture nor hide the names in the arguments.
+package macro
+
+import (
+	"monkey/ast"
+	"monkey/diag"
+	"monkey/evaluator"
+	"monkey/object"
+	"monkey/resolver"
+)
+
+// The number of times the code a macro expands to may itself contain a macro call
+const maxDepth = 100
+
+// Expand removes the macro definitions from program and replaces the calls to them by the code
+// they expand to. Macros that cannot be expanded are reported and their calls left in place.
+func Expand(program *ast.Program) diag.List {
+	e := &expander{macros: map[string]*ast.MacroLiteral{}, taken: map[string]bool{}}
+	ast.Inspect(program, func(node ast.Node) bool {
+		if id, ok := node.(*ast.Identifier); ok {
+			e.taken[id.Value] = true
+		}
+		return true
+	})
+	e.define(program)
+	ast.Modify(program, func(node ast.Node) ast.Node {
+		return e.expand(node, 0)
+	})
+	e.diags.Sort()
+	return e.diags
+}
+
+type expander struct {
+	macros map[string]*ast.MacroLiteral
+	taken  map[string]bool // The names in the program, which renamed names must not clash with
+	diags  diag.List
+}
+
+/** Definitions **/
+
+// Helper function that collects the `let name = macro(...) {...}` statements of the program and
+// removes them
+func (e *expander) define(program *ast.Program) {
+	kept := []ast.Statement{}
+	for _, stmt := range program.Statements {
+		if let, ok := stmt.(*ast.LET_Statement); ok {
+			id, isName := let.Name.(*ast.Identifier)
+			lit, isMacro := let.Value.(*ast.MacroLiteral)
+			if isName && isMacro {
+				e.macros[id.Value] = lit
+				continue
+			}
+		}
+		kept = append(kept, stmt)
+	}
+	program.Statements = kept
+
+	// what is left cannot be expanded
+	ast.Inspect(program, func(node ast.Node) bool {
+		if lit, ok := node.(*ast.MacroLiteral); ok {
+			e.diags.Errorf(lit.Token.Pos, "a macro must be defined by a let at the top level of the program")
+			return false
+		}
+		return true
+	})
+}
+
+/** Expansion **/
+
+// Helper function that replaces node, if it is a call to a macro, by the code the macro expands to
+func (e *expander) expand(node ast.Node, depth int) ast.Node {
+	call, ok := node.(*ast.CALL_Expression)
+	if !ok {
+		return node
+	}
+	name, ok := call.Function.(*ast.Identifier)
+	if !ok {
+		return node
+	}
+	lit, ok := e.macros[name.Value]
+	if !ok {
+		return node
+	}
+	if depth == maxDepth {
+		e.diags.Errorf(ast.Pos(call), "expansion of %s is nested more than %d deep", name.Value, maxDepth)
+		return node
+	}
+	code := e.expandCall(call, name.Value, lit)
+	if code == nil {
+		return node
+	}
+	// the code may call macros too
+	return ast.Modify(code, func(node ast.Node) ast.Node {
+		return e.expand(node, depth+1)
+	})
+}
+
+// Helper function that returns the code a call to the macro lit expands to, or nil if it cannot
+// be expanded
+func (e *expander) expandCall(call *ast.CALL_Expression, name string, lit *ast.MacroLiteral) ast.Expression {
+	for _, arg := range call.Arguments {
+		if kw, ok := arg.(*ast.KEYWORD_Argument); ok {
+			e.diags.Errorf(kw.Token.Pos, "macro %s cannot take keyword arguments", name)
+			return nil
+		}
+	}
+	if len(call.Arguments) != len(lit.Parameters) {
+		e.diags.Errorf(ast.Pos(call), "wrong number of arguments to macro %s: want %d, got %d", name, len(lit.Parameters), len(call.Arguments))
+		return nil
+	}
+	// each expansion runs a copy of the body of its own, in which the quoted code binds fresh names
+	body := ast.Copy(lit.Body).(*ast.BlockStatement)
+	e.renameQuoted(body)
+	env := object.NewEnvironment()
+	for i, p := range lit.Parameters {
+		env.Set(p.Value, &object.Quote{Node: call.Arguments[i]})
+	}
+
+	result := evaluator.Eval(body, env)
+	if rv, ok := result.(*object.ReturnValue); ok {
+		result = rv.Value
+	}
+	switch r := result.(type) {
+	case *object.Quote:
+		if code, ok := r.Node.(ast.Expression); ok {
+			return code
+		}
+	case *object.Error:
+		e.diags.Errorf(ast.Pos(call), "expanding macro %s: %s", name, r.Message)
+		return nil
+	case nil:
+		result = evaluator.NULL
+	}
+	e.diags.Errorf(ast.Pos(call), "macro %s must return quoted code, not %s", name, result.Inspect())
+	return nil
+}
+
+// Helper function that returns the argument of a call `name(x)`
+func callOf(name string, expr ast.Expression) (ast.Expression, bool) {
+	call, ok := expr.(*ast.CALL_Expression)
+	if !ok || len(call.Arguments) != 1 {
+		return nil, false
+	}
+	if id, ok := call.Function.(*ast.Identifier); !ok || id.Value != name {
+		return nil, false
+	}
+	return call.Arguments[0], true
+}
+
+/** Hygiene **/
+
+// Helper function that renames the names bound by the code in each quote(...) within node
+func (e *expander) renameQuoted(node ast.Node) {
+	ast.Inspect(node, func(node ast.Node) bool {
+		expr, ok := node.(ast.Expression)
+		if !ok {
+			return true
+		}
+		if code, ok := callOf("quote", expr); ok {
+			e.rename(code)
+			return false
+		}
+		return true
+	})
+}
+
+// Helper function that gives the names that quoted code binds names that are used nowhere else.
+// An unquote(...) in the code is evaluated in the macro body, so the names in it are left alone.
+func (e *expander) rename(code ast.Expression) {
+	// the resolver links every use of a name the code binds to the binding
+	resolver.Resolve(&ast.Program{Statements: []ast.Statement{&ast.EXPRESSION_Statement{Expression: code}}})
+	names := map[*ast.Identifier]string{}
+	ast.Inspect(code, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.CALL_Expression:
+			if arg, ok := callOf("unquote", n); ok {
+				// but the code it quotes in turn binds names too
+				e.renameQuoted(arg)
+				return false
+			}
+		case *ast.HASH_PatternEntry:
+			// the key of `{a}` is a key, which keeps its name when the variable a is renamed
+			if n.Value == ast.Pattern(n.Key) {
+				n.Key = &ast.Identifier{Token: n.Key.Token, Value: n.Key.Value}
+			}
+		case *ast.Identifier:
+			if n.Decl == nil {
+				return true
+			}
+			if _, ok := names[n.Decl]; !ok {
+				names[n.Decl] = e.fresh(n.Decl.Value)
+			}
+			n.Value = names[n.Decl]
+			n.Token.Literal = n.Value
+		}
+		return true
+	})
+}
+
+// Helper function that makes a name from base that is used nowhere else: base_a, base_b, ...
+// Identifiers are made of letters and underscores only.
+func (e *expander) fresh(base string) string {
+	for n := 0; ; n++ {
+		suffix := ""
+		for i := n; ; i = i/26 - 1 {
+			suffix = string(rune('a'+i%26)) + suffix
+			if i < 26 {
+				break
+			}
+		}
+		name := base + "_" + suffix
+		if !e.taken[name] {
+			e.taken[name] = true
+			return name
+		}
+	}
+}
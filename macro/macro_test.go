@@ -0,0 +1,148 @@
+package macro
+
+import (
+	"monkey/ast"
+	"monkey/evaluator"
+	"monkey/format"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"reflect"
+	"testing"
+)
+
+// Helper function that parses input and fails the test on a parse error
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+	ps := parser.New(lexer.New(input))
+	program := ps.ParseProgram()
+	if errs := ps.Errors(); len(errs) > 0 {
+		t.Fatalf("parsing %q: %s", input, errs)
+	}
+	return program
+}
+
+func TestExpand(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string // compared once both are formatted
+	}{
+		{
+			"let unless = macro(cond, yes, no) { quote(if (!(unquote(cond))) { unquote(yes) } else { unquote(no) }) }; unless(10 > 5, a, b);",
+			"if (!(10 > 5)) { a } else { b };",
+		},
+		// calls before the definition are expanded too, every use of a parameter gets the argument
+		{"twice(f(1)); let twice = macro(e) { quote(unquote(e) + unquote(e)) };", "f(1) + f(1);"},
+		// the body is evaluated: it may compute with lets, ifs and functions before it quotes
+		{"let big = macro() { let k = 2 * 8; quote(unquote(k) * x) }; big();", "16 * x;"},
+		{"let pick = macro(a, b) { if (1 > 2) { a } else { b } }; pick(x + 1, y - 1);", "y - 1;"},
+		{"let m = macro(a) { let wrap = fn(q) { quote(-(unquote(q))) }; wrap(wrap(a)) }; m(z);", "-(-(z));"},
+		{"let m = macro() { return quote(1); 2 }; m();", "1;"},
+		// code a macro returns may call macros, which are expanded in turn
+		{"let inc = macro(x) { quote(unquote(x) + 1) }; let inctwice = macro(x) { quote(inc(inc(unquote(x)))) }; inctwice(a);", "a + 1 + 1;"},
+		// a macro call in an argument is expanded once it is spliced in
+		{"let neg = macro(x) { quote(-unquote(x)) }; neg(neg(a));", "-(-a);"},
+		{"let m = macro(x) { quote(unquote(x)) }; let y = fn(a) { m(a) };", "let y = fn(a) { a };"},
+	}
+	for _, tt := range tests {
+		program := parse(t, tt.input)
+		if diags := Expand(program); len(diags) > 0 {
+			t.Errorf("%q: unexpected diagnostics %v", tt.input, diags)
+			continue
+		}
+		if got, want := format.Node(program), format.Node(parse(t, tt.want)); got != want {
+			t.Errorf("%q:\ngot:\n%s\nwant:\n%s", tt.input, got, want)
+		}
+	}
+}
+
+func TestHygiene(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		// the x the macro binds cannot capture the x of the argument
+		{
+			"let addx = macro(e) { quote(fn(x) { x + unquote(e) }) }; addx(x * 2);",
+			"fn(x_a) { x_a + x * 2 };",
+		},
+		{
+			"let swap = macro(a, b) { quote(fn() { let tmp = unquote(a); unquote(b) + tmp }) }; let tmp = 1; swap(tmp, 2);",
+			"let tmp = 1; fn() { let tmp_a = tmp; 2 + tmp_a };",
+		},
+		// every expansion gets names of its own, which avoid the names already in the program
+		{
+			"let m = macro() { quote(fn(x) { x }) }; let x_a = 1; m(); m();",
+			"let x_a = 1; fn(x_b) { x_b }; fn(x_c) { x_c };",
+		},
+		// patterns and match arms bind names too; the key of a shorthand hash entry is not a name
+		{
+			"let m = macro(e) { quote(match (unquote(e)) { [h, ...t] => h, {k} => k, n => n }) }; m(t);",
+			"match (t) { [h_a, ...t_a] => h_a, {k: k_a} => k_a, n_a => n_a };",
+		},
+		// names that the quoted code uses without binding them are the caller's
+		{"let m = macro() { quote(f(y)) }; m();", "f(y);"},
+		// the names inside unquote belong to the macro body, not to the quoted code
+		{"let m = macro(x) { quote(fn(x) { unquote(x) }) }; m(y);", "fn(x_a) { y };"},
+		{
+			"let m = macro(e) { let inner = quote(fn(v) { v }); quote(fn(v) { unquote(inner)(unquote(e)) }) }; m(v);",
+			"fn(v_b) { fn(v_a) { v_a }(v) };",
+		},
+	}
+	for _, tt := range tests {
+		program := parse(t, tt.input)
+		if diags := Expand(program); len(diags) > 0 {
+			t.Errorf("%q: unexpected diagnostics %v", tt.input, diags)
+			continue
+		}
+		if got, want := format.Node(program), format.Node(parse(t, tt.want)); got != want {
+			t.Errorf("%q:\ngot:\n%s\nwant:\n%s", tt.input, got, want)
+		}
+	}
+}
+
+func TestExpandedProgramRuns(t *testing.T) {
+	program := parse(t, `
+		let unless = macro(cond, yes, no) { quote(if (!(unquote(cond))) { unquote(yes) } else { unquote(no) }) };
+		let addx = macro(e) { quote(fn(x) { x + unquote(e) }) };
+		let x = 100;
+		let f = addx(x);
+		unless(f(1) > 200, f(1), 0);
+	`)
+	if diags := Expand(program); len(diags) > 0 {
+		t.Fatalf("unexpected diagnostics %v", diags)
+	}
+	if got := evaluator.Eval(program, object.NewEnvironment()).Inspect(); got != "101" {
+		t.Errorf("got %s, want 101", got)
+	}
+}
+
+func TestExpandErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"let f = fn() { macro(x) { x } };", []string{"1:16: error: a macro must be defined by a let at the top level of the program"}},
+		{"let m = macro(a) { a }; m(1, 2);", []string{"1:25: error: wrong number of arguments to macro m: want 1, got 2"}},
+		{"let m = macro(a) { a }; m(a: 1);", []string{"1:27: error: macro m cannot take keyword arguments"}},
+		{"let m = macro() { 1 }; m();", []string{"1:24: error: macro m must return quoted code, not 1"}},
+		{"let m = macro() { let a = 1; }; m();", []string{"1:33: error: macro m must return quoted code, not null"}},
+		{"let m = macro(a) { a + 1 }; m(2);", []string{"1:29: error: expanding macro m: type mismatch: QUOTE + INTEGER"}},
+		{"let m = macro() { unquote(1) }; m();", []string{"1:33: error: expanding macro m: unquote outside of quote"}},
+		{"let m = macro() { quote(unquote(y)) }; m();", []string{"1:40: error: expanding macro m: identifier not found: y"}},
+		{
+			"let m = macro() { quote(unquote(fn() { 1 })) }; m();",
+			[]string{"1:49: error: expanding macro m: cannot unquote fn() { 1; }: only integers, booleans and quoted code can be turned into code"},
+		},
+		{"let loop = macro() { quote(loop()) }; loop();", []string{"1:28: error: expansion of loop is nested more than 100 deep"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, d := range Expand(parse(t, tt.input)) {
+			got = append(got, d.String())
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q:\n got %q\nwant %q", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// Command monkey parses and runs Monkey programs and works with their syntax trees.
+//
+// Usage:
+//
+//	monkey [-trace] [-O] [file]         parse a program and print it back
+//	monkey ast [-json | -dot] [file]    print the syntax tree of a program
+//	monkey fmt [-w] [-l] [-d] [files]   format programs in the canonical style
+//	monkey expand [file]                print a program with its macros expanded
+//	monkey run [-max-steps n] [-max-depth n] [-timeout d] [-max-memory n] [-memstats] [file]
+//	                                    run a program and print its value
+//	monkey check [-types] [file]        report undefined names and type errors
+//	monkey vet [-config file] [-json] [files]
+//	                                    report likely mistakes
+//
+// Programs are read from file, or from standard input when no file is given.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"monkey/ast"
+	"monkey/lexer"
+	"monkey/optimize"
+	"monkey/parser"
+	"os"
+)
+
+func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "ast":
+			os.Exit(astCommand(os.Args[2:]))
+		case "fmt":
+			os.Exit(fmtCommand(os.Args[2:]))
+		case "expand":
+			os.Exit(expandCommand(os.Args[2:]))
+		case "run":
+			os.Exit(runCommand(os.Args[2:]))
+		case "check":
+			os.Exit(checkCommand(os.Args[2:]))
+		case "vet":
+			os.Exit(vetCommand(os.Args[2:]))
+		}
+	}
+	os.Exit(parseCommand(os.Args[1:]))
+}
+
+// monkey [-trace] [-O] [file]
+func parseCommand(args []string) int {
+	flags := flag.NewFlagSet("monkey", flag.ExitOnError)
+	trace := flags.Bool("trace", false, "log every parse function the parser enters and leaves to standard error")
+	optimized := flags.Bool("O", false, "print the program after constant folding and simplification")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: monkey [-trace] [-O] [file]\n       monkey ast [-json | -dot] [file]\n       monkey fmt [-w] [-l] [-d] [files...]\n       monkey expand [file]\n       monkey run [-max-steps n] [-max-depth n] [-timeout d] [-max-memory n] [-memstats] [file]\n       monkey check [-types] [file]\n       monkey vet [-config file] [-json] [files...]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+
+	var tracer io.Writer
+	if *trace {
+		tracer = os.Stderr
+	}
+	program, ok := parseFile(flags.Arg(0), tracer)
+	if !ok {
+		return 1
+	}
+	if *optimized {
+		optimize.Optimize(program)
+	}
+	fmt.Print(program.Node_String())
+	return 0
+}
+
+// Helper function that parses the named file, or standard input if name is empty, and reports
+// any syntax errors on standard error. tracer, if not nil, receives the parser's trace.
+func parseFile(name string, tracer io.Writer) (*ast.Program, bool) {
+	src, err := readSource(name)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return nil, false
+	}
+	return parseSource(name, src, tracer)
+}
+
+// Helper function that parses src, read from the named file, and reports any syntax errors on
+// standard error
+func parseSource(name string, src []byte, tracer io.Writer) (*ast.Program, bool) {
+	ps := parser.New(lexer.New(string(src)))
+	ps.SetTrace(tracer)
+	program := ps.ParseProgram()
+	if len(ps.Errors()) > 0 {
+		for _, err := range ps.Errors() {
+			fmt.Fprintf(os.Stderr, "%s:%s\n", sourceName(name), err)
+		}
+		return nil, false
+	}
+	return program, true
+}
+
+// Helper function that reads the named file, or standard input if name is empty
+func readSource(name string) ([]byte, error) {
+	if name == "" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(name)
+}
+
+// Helper function that names the source in error messages
+func sourceName(name string) string {
+	if name == "" {
+		return "<stdin>"
+	}
+	return name
+}
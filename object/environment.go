@@ -0,0 +1,43 @@
+package object
+
+// The environment maps names to the values they are bound to
+type Environment struct {
+	store map[string]Object
+	outer *Environment // The enclosing environment, nil for the global one
+}
+
+// Helper function to create a new global Environment
+func NewEnvironment() *Environment {
+	return &Environment{store: make(map[string]Object)}
+}
+
+// Helper function to create an Environment nested in outer, e.g. for the body of a function call
+func NewEnclosedEnvironment(outer *Environment) *Environment {
+	env := NewEnvironment()
+	env.outer = outer
+	return env
+}
+
+// Get looks name up in the environment and then in the enclosing ones
+func (env *Environment) Get(name string) (Object, bool) {
+	obj, ok := env.store[name]
+	if !ok && env.outer != nil {
+		return env.outer.Get(name)
+	}
+	return obj, ok
+}
+
+// Set binds name in this environment
+func (env *Environment) Set(name string, val Object) Object {
+	env.store[name] = val
+	return val
+}
+
+// Values returns the values bound in this environment, not those of the enclosing ones
+func (env *Environment) Values() []Object {
+	values := make([]Object, 0, len(env.store))
+	for _, val := range env.store {
+		values = append(values, val)
+	}
+	return values
+}
@@ -0,0 +1,115 @@
+package object
+
+import (
+	"bytes"
+	"fmt"
+	"monkey/ast"
+	"monkey/token"
+	"strings"
+)
+
+type ObjectType string
+
+// The types of the values a program works with
+const (
+	INTEGER_OBJ      = "INTEGER"
+	BOOLEAN_OBJ      = "BOOLEAN"
+	NULL_OBJ         = "NULL"
+	RETURN_VALUE_OBJ = "RETURN_VALUE" // Wraps the value of a return statement while it unwinds the blocks
+	ERROR_OBJ        = "ERROR"
+	FUNCTION_OBJ     = "FUNCTION"
+	ARRAY_OBJ        = "ARRAY" // Only built by rest parameters, the language has no array literals
+	QUOTE_OBJ        = "QUOTE" // Code that quote(...) leaves unevaluated, see package macro
+)
+
+type Object interface {
+	Type() ObjectType
+	Inspect() string // Returns the value as the programmer would write it
+}
+
+/** Integer **/
+type Integer struct {
+	Value int64
+}
+
+func (i *Integer) Type() ObjectType { return INTEGER_OBJ }
+func (i *Integer) Inspect() string  { return fmt.Sprintf("%d", i.Value) }
+
+/** Boolean **/
+type Boolean struct {
+	Value bool
+}
+
+func (b *Boolean) Type() ObjectType { return BOOLEAN_OBJ }
+func (b *Boolean) Inspect() string  { return fmt.Sprintf("%t", b.Value) }
+
+/** Null **/
+type Null struct{}
+
+func (n *Null) Type() ObjectType { return NULL_OBJ }
+func (n *Null) Inspect() string  { return "null" }
+
+/** Return Value **/
+type ReturnValue struct {
+	Value Object
+}
+
+func (rv *ReturnValue) Type() ObjectType { return RETURN_VALUE_OBJ }
+func (rv *ReturnValue) Inspect() string  { return rv.Value.Inspect() }
+
+/** Error **/
+type Error struct {
+	Message string
+	Pos     token.Position // Where the error happened, the zero Position until the evaluator knows
+	Stack   []Frame        // The calls the error unwound, the innermost first
+	Err     error          // The Go error behind the message, e.g. a limit evaluation ran into, nil for most
+}
+
+// A Frame is a call that was running when an error happened
+type Frame struct {
+	Function string         // The name the function was defined with, <anonymous> if it has none
+	Call     token.Position // The position of the call
+}
+
+func (e *Error) Type() ObjectType { return ERROR_OBJ }
+func (e *Error) Inspect() string  { return "ERROR: " + e.Message }
+
+// An Error is a Go error too, which unwraps to Err
+func (e *Error) Error() string { return e.Message }
+func (e *Error) Unwrap() error { return e.Err }
+
+/** Function **/
+type Function struct {
+	Name    string               // The name a let bound the literal to, empty for an anonymous function
+	Literal *ast.FunctionLiteral // The parameters and the body of the function
+	Env     *Environment         // The environment the function was defined in
+}
+
+func (f *Function) Type() ObjectType { return FUNCTION_OBJ }
+func (f *Function) Inspect() string  { return f.Literal.Node_String() }
+
+/** Array **/
+type Array struct {
+	Elements []Object
+}
+
+func (a *Array) Type() ObjectType { return ARRAY_OBJ }
+func (a *Array) Inspect() string {
+	var out bytes.Buffer
+	elements := []string{}
+	for _, el := range a.Elements {
+		elements = append(elements, el.Inspect())
+	}
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+	return out.String()
+}
+
+/** Quote **/
+type Quote struct {
+	Node ast.Node // The quoted code, with its unquote(...) calls replaced
+}
+
+func (q *Quote) Type() ObjectType { return QUOTE_OBJ }
+func (q *Quote) Inspect() string  { return "QUOTE(" + q.Node.Node_String() + ")" }
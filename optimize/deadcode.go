@@ -0,0 +1,120 @@
+package optimize
+
+import (
+	"monkey/ast"
+	"monkey/diag"
+)
+
+// Unreachable reports the code in program that can never run, as warnings: the statements after
+// a statement that always returns, and the branch of an if-expression whose condition is constant,
+// e.g. `if (1 > 2)`, unless it is empty. Unreachable code inside unreachable code is not reported again.
+// The program is not changed; Optimize removes what is reported.
+func Unreachable(program *ast.Program) diag.List {
+	var diags diag.List
+	var visit func(node ast.Node) bool
+	visit = func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.Program:
+			reportAfterReturn(&diags, n.Statements)
+		case *ast.BlockStatement:
+			reportAfterReturn(&diags, n.Statemens)
+		case *ast.IF_Expression:
+			value, ok := constantCondition(n.Condition)
+			if !ok {
+				return true
+			}
+			// only the branch that runs is visited
+			ast.Inspect(n.Condition, visit)
+			switch {
+			case value:
+				ast.Inspect(n.Consequence, visit)
+				if n.Alternative != nil && len(n.Alternative.Statemens) > 0 {
+					diags.Warnf(n.Alternative.Token.Pos, "unreachable code: the condition at %s is always true", ast.Pos(n.Condition))
+				}
+			default:
+				if len(n.Consequence.Statemens) > 0 {
+					diags.Warnf(n.Consequence.Token.Pos, "unreachable code: the condition at %s is always false", ast.Pos(n.Condition))
+				}
+				if n.Alternative != nil {
+					ast.Inspect(n.Alternative, visit)
+				}
+			}
+			return false
+		}
+		return true
+	}
+	ast.Inspect(program, visit)
+	diags.Sort()
+	return diags
+}
+
+// Helper function that reports the first statement after one that always returns
+func reportAfterReturn(diags *diag.List, list []ast.Statement) {
+	if n := len(reachable(list)); n < len(list) {
+		diags.Warnf(ast.Pos(list[n]), "unreachable code after return")
+	}
+}
+
+// Helper function that returns the statements of a list up to the first one that always returns
+func reachable(list []ast.Statement) []ast.Statement {
+	for i, stmt := range list {
+		if returns(stmt) {
+			return list[:i+1]
+		}
+	}
+	return list
+}
+
+// Helper function that reports whether running a statement always ends in a return
+func returns(stmt ast.Statement) bool {
+	switch s := stmt.(type) {
+	case *ast.RETURN_Statement:
+		return true
+	case *ast.BlockStatement:
+		return listReturns(s.Statemens)
+	case *ast.EXPRESSION_Statement:
+		ie, ok := s.Expression.(*ast.IF_Expression)
+		if !ok {
+			return false
+		}
+		if value, ok := constantCondition(ie.Condition); ok {
+			if !value {
+				return ie.Alternative != nil && listReturns(ie.Alternative.Statemens)
+			}
+			return listReturns(ie.Consequence.Statemens)
+		}
+		return ie.Alternative != nil && listReturns(ie.Consequence.Statemens) && listReturns(ie.Alternative.Statemens)
+	}
+	return false
+}
+
+func listReturns(list []ast.Statement) bool {
+	for _, stmt := range list {
+		if returns(stmt) {
+			return true
+		}
+	}
+	return false
+}
+
+// Helper function that evaluates a condition made of literals, without changing it
+func constantCondition(expr ast.Expression) (value bool, ok bool) {
+	b, ok := constantValue(expr).(*ast.Boolean)
+	if !ok {
+		return false, false
+	}
+	return b.Value, true
+}
+
+// Helper function that returns the literal an expression folds to, or the expression itself if it does not fold
+func constantValue(expr ast.Expression) ast.Expression {
+	switch e := expr.(type) {
+	case *ast.PREFIX_Expression:
+		return foldPrefix(&ast.PREFIX_Expression{Token: e.Token, Right: constantValue(e.Right)})
+	case *ast.INFIX_Expression:
+		if value, ok := constant(e.Token, constantValue(e.Left), constantValue(e.Right)); ok {
+			return value
+		}
+	}
+	return expr
+}
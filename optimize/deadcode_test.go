@@ -0,0 +1,80 @@
+package optimize
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUnreachable(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"let x = 1; x", nil},
+		{"return 1; 2", []string{"1:11: warning: unreachable code after return"}},
+		{"fn() { return 1; let x = 2; x }", []string{"1:18: warning: unreachable code after return"}},
+		// only the first unreachable statement of a list is reported
+		{"fn() { return 1; a; b; return 2; c }", []string{"1:18: warning: unreachable code after return"}},
+		{"fn() { if (x) { return 1; } else { return 2; }; a }", []string{"1:49: warning: unreachable code after return"}},
+		{"fn() { if (x) { return 1; }; a }", nil},
+		// nested blocks
+		{"fn() { if (x) { return 1; 2 } else { fn() { return 3; 4 } } }", []string{
+			"1:27: warning: unreachable code after return",
+			"1:55: warning: unreachable code after return",
+		}},
+		{"fn() { fn() { fn() { return 1; 2 } } }", []string{"1:32: warning: unreachable code after return"}},
+		// constant conditions
+		{"if (false) { a }", []string{"1:12: warning: unreachable code: the condition at 1:5 is always false"}},
+		{"if (1 > 2) { a } else { b }", []string{"1:12: warning: unreachable code: the condition at 1:5 is always false"}},
+		{"if (!false) { a } else { b }", []string{"1:24: warning: unreachable code: the condition at 1:5 is always true"}},
+		{"if (true) { a }", nil},
+		{"if (false) {} else { a }", nil},
+		{"if (true) { a } else {}", nil},
+		{"if (x) { a } else { b }", nil},
+		{"if (1 / 0 == 1) { a } else { b }", nil},
+		{"fn() { if (true) { return 1; }; a }", []string{"1:33: warning: unreachable code after return"}},
+		{"fn() { if (false) { return 1; }; a }", []string{"1:19: warning: unreachable code: the condition at 1:12 is always false"}},
+		// unreachable code inside unreachable code is not reported again
+		{"if (false) { return 1; a }", []string{"1:12: warning: unreachable code: the condition at 1:5 is always false"}},
+		{"if (true) { a } else { if (false) { b } }", []string{"1:22: warning: unreachable code: the condition at 1:5 is always true"}},
+	}
+	for _, tt := range tests {
+		program := parse(t, tt.input)
+		before := program.Node_String()
+		var got []string
+		for _, d := range Unreachable(program) {
+			got = append(got, d.String())
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %q, want %q", tt.input, got, tt.want)
+		}
+		if program.Node_String() != before {
+			t.Errorf("%q: Unreachable changed the program", tt.input)
+		}
+	}
+}
+
+func TestOptimizeRemovesUnreachable(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"return 1; 2", "return 1;"},
+		{"fn() { return 1; let x = 2; x }", "fn() { return 1; };"},
+		{"fn() { if (x) { return 1; 2 } else { return 3; }; a }", "fn() { if (x) { return 1; } else { return 3; }; };"},
+		{"fn() { if (true) { return 1; }; a }", "fn() { return 1; };"},
+		{"fn() { if (true) { let a = 1; return a; }; b }", "fn() { if (true) { let a = 1; return a; }; };"},
+		{"let y = if (false) { a; b } else { c; d };", "let y = if (true) { c; d; };"},
+		{"let y = if (false) { a; b };", "let y = if (false) { };"},
+	}
+	for _, tt := range tests {
+		program := Optimize(parse(t, tt.input))
+		if got := strings.TrimSpace(strings.ReplaceAll(program.Node_String(), "\n", " ")); got != tt.want {
+			t.Errorf("%q: got %s, want %s", tt.input, got, tt.want)
+		}
+		if diags := Unreachable(program); len(diags) > 0 {
+			t.Errorf("%q: optimized program still has %s", tt.input, diags[0])
+		}
+	}
+}
@@ -0,0 +1,238 @@
+// Package optimize rewrites Monkey programs into simpler programs that behave the same.
+//
+// Only rewrites that cannot change what a program does are made: an operation is folded only
+// when it is certain to succeed, so `1 / 0` and `-true` are left for the program to fail on
+// when it runs, and `!5`, which depends on the truthiness rules of the evaluator, is left alone.
+package optimize
+
+import (
+	"monkey/ast"
+	"monkey/token"
+	"strconv"
+)
+
+// Optimize rewrites program in place and returns it:
+//   - constant operands of prefix and infix expressions are folded, e.g. `2 * 60 * 60` to `7200`
+//     and `!true` to `false`
+//   - constants at the end of a chain of +, - or * are combined, e.g. `x * 2 * 30` to `x * 60`
+//   - an if-expression whose condition is the constant true or false is replaced by the branch
+//     that is taken
+//   - unreachable code is removed: the statements after a return, see Unreachable
+func Optimize(program *ast.Program) *ast.Program {
+	ast.Modify(program, fold)
+	simplifyBranches(program)
+	return program
+}
+
+/** Folding **/
+
+// Helper function that is the ast.ModifierFunc doing the folding; children are folded before their parents
+func fold(node ast.Node) ast.Node {
+	switch n := node.(type) {
+	case *ast.PREFIX_Expression:
+		return foldPrefix(n)
+	case *ast.INFIX_Expression:
+		return foldInfix(n)
+	case *ast.IF_Expression:
+		return foldIf(n)
+	}
+	return node
+}
+
+func foldIf(ie *ast.IF_Expression) ast.Expression {
+	cond, ok := ie.Condition.(*ast.Boolean)
+	if !ok {
+		return ie
+	}
+	taken, _ := takenBranch(ie)
+	// in an expression, only a branch that is a single expression can replace the if
+	if taken != nil && len(taken.Statemens) == 1 {
+		if stmt, ok := taken.Statemens[0].(*ast.EXPRESSION_Statement); ok && stmt.Expression != nil {
+			return stmt.Expression
+		}
+	}
+	// otherwise the branch that is never run is dropped: `if (true) { taken }` or `if (false) {}`
+	switch {
+	case taken != nil:
+		ie.Condition = boolean(cond.Token, true)
+		ie.Consequence, ie.Alternative = taken, nil
+	default:
+		ie.Consequence.Statemens = []ast.Statement{}
+	}
+	return ie
+}
+
+func foldPrefix(pe *ast.PREFIX_Expression) ast.Expression {
+	switch right := pe.Right.(type) {
+	case *ast.INTEGER_Literal:
+		if pe.Token.Type == token.MINUS {
+			return integer(pe.Token, -right.Value)
+		}
+	case *ast.Boolean:
+		if pe.Token.Type == token.BANG {
+			return boolean(pe.Token, !right.Value)
+		}
+	}
+	return pe
+}
+
+func foldInfix(ie *ast.INFIX_Expression) ast.Expression {
+	if value, ok := constant(ie.Token, ie.Left, ie.Right); ok {
+		return value
+	}
+	// `(x op a) op b` becomes `x op (a op b)`, which is exact with wrapping integer arithmetic
+	inner, ok := ie.Left.(*ast.INFIX_Expression)
+	if !ok || !isInteger(ie.Right) || !isInteger(inner.Right) {
+		return ie
+	}
+	combine := ie.Token.Type
+	switch {
+	case inner.Token.Type == token.PLUS && ie.Token.Type == token.PLUS,
+		inner.Token.Type == token.ASTERISK && ie.Token.Type == token.ASTERISK:
+	case inner.Token.Type == token.MINUS && ie.Token.Type == token.MINUS:
+		// (x - a) - b is x - (a + b)
+		combine = token.PLUS
+	default:
+		return ie
+	}
+	value, ok := constant(token.Token{Type: combine, Literal: string(combine), Pos: ie.Token.Pos}, inner.Right, ie.Right)
+	if !ok {
+		return ie
+	}
+	return &ast.INFIX_Expression{Token: inner.Token, Left: inner.Left, Right: value}
+}
+
+// Helper function that evaluates `left op right` if both are literals and the operation cannot fail
+func constant(op token.Token, left, right ast.Expression) (ast.Expression, bool) {
+	switch l := left.(type) {
+	case *ast.INTEGER_Literal:
+		r, ok := right.(*ast.INTEGER_Literal)
+		if !ok {
+			return nil, false
+		}
+		switch op.Type {
+		case token.PLUS:
+			return integer(op, l.Value+r.Value), true
+		case token.MINUS:
+			return integer(op, l.Value-r.Value), true
+		case token.ASTERISK:
+			return integer(op, l.Value*r.Value), true
+		case token.SLASH:
+			// division by zero is a runtime error the program must still raise
+			if r.Value == 0 {
+				return nil, false
+			}
+			return integer(op, l.Value/r.Value), true
+		case token.LT:
+			return boolean(op, l.Value < r.Value), true
+		case token.GT:
+			return boolean(op, l.Value > r.Value), true
+		case token.EQ:
+			return boolean(op, l.Value == r.Value), true
+		case token.NOT_EQ:
+			return boolean(op, l.Value != r.Value), true
+		}
+	case *ast.Boolean:
+		r, ok := right.(*ast.Boolean)
+		if !ok {
+			return nil, false
+		}
+		switch op.Type {
+		case token.EQ:
+			return boolean(op, l.Value == r.Value), true
+		case token.NOT_EQ:
+			return boolean(op, l.Value != r.Value), true
+		}
+	}
+	return nil, false
+}
+
+func isInteger(expr ast.Expression) bool {
+	_, ok := expr.(*ast.INTEGER_Literal)
+	return ok
+}
+
+// Helper function that makes an INTEGER_Literal at the position of the token it was folded from.
+// A negative value keeps its sign in the token, so that it prints as `-5`.
+func integer(at token.Token, value int64) *ast.INTEGER_Literal {
+	return &ast.INTEGER_Literal{Token: token.Token{Type: token.INT, Literal: strconv.FormatInt(value, 10), Pos: at.Pos}, Value: value}
+}
+
+func boolean(at token.Token, value bool) *ast.Boolean {
+	tok := token.Token{Type: token.FALSE, Literal: "false", Pos: at.Pos}
+	if value {
+		tok.Type, tok.Literal = token.TRUE, "true"
+	}
+	return &ast.Boolean{Token: tok, Value: value}
+}
+
+/** Branches **/
+
+// Helper function that returns the branch an if-expression with a constant condition takes, which
+// is nil for a false condition without else. ok is false if the condition is not constant.
+func takenBranch(ie *ast.IF_Expression) (taken *ast.BlockStatement, ok bool) {
+	cond, ok := ie.Condition.(*ast.Boolean)
+	if !ok {
+		return nil, false
+	}
+	if cond.Value {
+		return ie.Consequence, true
+	}
+	return ie.Alternative, true
+}
+
+// Helper function that replaces if statements with constant conditions by the statements of
+// the branch they take, and drops the statements that can never run, in every list of
+// statements of the program
+func simplifyBranches(program *ast.Program) {
+	program.Statements = reachable(simplifyStatements(program.Statements))
+	ast.Inspect(program, func(node ast.Node) bool {
+		if block, ok := node.(*ast.BlockStatement); ok {
+			block.Statemens = reachable(simplifyStatements(block.Statemens))
+		}
+		return true
+	})
+}
+
+func simplifyStatements(list []ast.Statement) []ast.Statement {
+	result := []ast.Statement{}
+	for i, stmt := range list {
+		last := i == len(list)-1
+		es, ok := stmt.(*ast.EXPRESSION_Statement)
+		if !ok {
+			result = append(result, stmt)
+			continue
+		}
+		ie, ok := es.Expression.(*ast.IF_Expression)
+		if !ok {
+			result = append(result, stmt)
+			continue
+		}
+		taken, ok := takenBranch(ie)
+		switch {
+		case !ok:
+			result = append(result, stmt)
+		case taken == nil || len(taken.Statemens) == 0:
+			// the if does nothing, but the last statement of a list is its value
+			if last {
+				result = append(result, stmt)
+			}
+		case declares(taken):
+			// the branch's lets must stay in their own scope
+			result = append(result, stmt)
+		default:
+			result = append(result, simplifyStatements(taken.Statemens)...)
+		}
+	}
+	return result
+}
+
+// Helper function that reports whether a block binds names with let
+func declares(block *ast.BlockStatement) bool {
+	for _, stmt := range block.Statemens {
+		if _, ok := stmt.(*ast.LET_Statement); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package optimize
+
+import (
+	"monkey/ast"
+	"monkey/evaluator"
+	"monkey/lexer"
+	"monkey/object"
+	"monkey/parser"
+	"strings"
+	"testing"
+)
+
+// Helper function that parses input and fails the test on a parse error
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+	ps := parser.New(lexer.New(input))
+	program := ps.ParseProgram()
+	if errs := ps.Errors(); len(errs) > 0 {
+		t.Fatalf("parsing %q: %s", input, errs)
+	}
+	return program
+}
+
+func TestOptimize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		// folding
+		{"2 * 60 * 60", "7200;"},
+		{"1 + 2 * 3 - 4 / 2", "5;"},
+		{"-5 + 1", "-4;"},
+		{"--5", "5;"},
+		{"!true", "false;"},
+		{"!!false", "false;"},
+		{"1 < 2 == true", "true;"},
+		{"3 != 3", "false;"},
+		{"true == false", "false;"},
+		{"let x = 2 * 3; x * (1 + 1)", "let x = 6; (x*2);"},
+		{"fn(a = 1 + 1) { a * 2 * 30 }", "fn(a = 2) { (a*60); };"},
+		{"x + 1 + 2", "(x+3);"},
+		{"x - 1 - 2", "(x-3);"},
+		{"x * 2 * 3", "(x*6);"},
+		// mixing operators, or a non-constant on the right, would change the result
+		{"x - 1 + 2", "((x-1)+2);"},
+		{"x / 2 / 3", "((x/2)/3);"},
+		{"1 + x + 2", "((1+x)+2);"},
+		// operations that fail, or depend on the evaluator's truthiness rules, are left alone
+		{"1 / 0", "(1/0);"},
+		{"10 / (5 - 5)", "(10/0);"},
+		{"x / 0 / 2", "((x/0)/2);"},
+		{"!5", "(!5);"},
+		{"-true", "(-true);"},
+		{"1 + true", "(1+true);"},
+		{"1 == true", "(1==true);"},
+		{"true + false", "(true+false);"},
+		{"true < false", "(true<false);"},
+		// branches
+		{"let y = if (true) { 1 } else { 2 };", "let y = 1;"},
+		{"let y = if (1 > 2) { 1 } else { 2 };", "let y = 2;"},
+		{"let y = if (x) { 1 } else { 2 };", "let y = if (x) { 1; } else { 2; };"},
+		{"let y = if (true) { a; b } else { 2 };", "let y = if (true) { a; b; };"},
+		{"if (true) { a; b } else { c }; d", "a; b; d;"},
+		{"if (false) { a }; d", "d;"},
+		{"d; if (false) { a }", "d; if (false) { };"},
+		{"if (false) { a } else { if (true) { b } }; d", "b; d;"},
+		{"if (true) { let a = 1; a }; d", "if (true) { let a = 1; a; }; d;"},
+		{"fn() { if (true) { return 1; }; 2 }", "fn() { return 1; };"},
+	}
+	for _, tt := range tests {
+		program := Optimize(parse(t, tt.input))
+		// statements print one per line
+		if got := strings.TrimSpace(strings.ReplaceAll(program.Node_String(), "\n", " ")); got != tt.want {
+			t.Errorf("%q: got %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestOptimizeKeepsSemantics(t *testing.T) {
+	tests := []string{
+		"2 * 60 * 60",
+		"let x = 5; x * 2 * 3 - 1 - 2",
+		"1 / 0",
+		"let x = 0; 1 + 2 / x",
+		"-true",
+		"!5",
+		"!!0",
+		"if (1 < 2) { 10 } else { 20 }",
+		"let f = fn(n) { if (true) { return n * 2 * 2; }; 0 }; f(3)",
+		"let a = 1; if (true) { let a = 2; a }; a",
+		"let f = fn(x, y = 1 + 1) { x - 1 - y }; f(10)",
+		"match (2 * 3) { 6 => !false, _ => 0 }",
+		"if (false) { 1 }",
+	}
+	for _, input := range tests {
+		want := evaluator.Eval(parse(t, input), object.NewEnvironment()).Inspect()
+		got := evaluator.Eval(Optimize(parse(t, input)), object.NewEnvironment()).Inspect()
+		if got != want {
+			t.Errorf("%q: optimized program gives %s, want %s", input, got, want)
+		}
+	}
+}
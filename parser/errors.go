@@ -0,0 +1,29 @@
+package parser
+
+import (
+	"fmt"
+	"monkey/token"
+)
+
+// An Error is a syntax error at a position in the source
+type Error struct {
+	Pos token.Position
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return e.Pos.String() + ": " + e.Msg
+}
+
+// An ErrorList is the list of errors found while parsing; it implements error
+type ErrorList []*Error
+
+func (el ErrorList) Error() string {
+	switch len(el) {
+	case 0:
+		return "no errors"
+	case 1:
+		return el[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", el[0], len(el)-1)
+}
@@ -0,0 +1,27 @@
+package parser
+
+import (
+	"monkey/ast"
+	"monkey/lexer"
+	"monkey/token"
+)
+
+// ParseExpression parses src as a single expression, e.g. a formula such as `price * qty > 100`
+// read from a config file. The whole input must be consumed: statements and trailing tokens are
+// errors. The returned error is an ErrorList.
+func ParseExpression(src string) (ast.Expression, error) {
+	ps := New(lexer.New(src))
+	switch ps.currentToken.Type {
+	case token.LET, token.RETURN:
+		ps.addError("expected an expression, got a %s statement", ps.currentToken.Literal)
+		return nil, ps.errors
+	}
+	expr := ps._parseExpression(LOWEST)
+	if len(ps.errors) == 0 && !ps.peekTokenIs(token.EOF) {
+		ps.errorAt(ps.peekToken.Pos, "unexpected %s after the expression", describeToken(ps.peekToken))
+	}
+	if len(ps.errors) > 0 {
+		return nil, ps.errors
+	}
+	return expr, nil
+}
@@ -0,0 +1,91 @@
+package parser
+
+import (
+	"monkey/token"
+	"testing"
+)
+
+func TestParseExpression(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"price * qty > 100", "((price*qty)>100)"},
+		{"  1  ", "1"},
+		{"-a + b", "((-a)+b)"},
+		{"f(x, y: 2)", "f(x, y: 2)"},
+		{"x |> f", "f(x)"},
+		{"(a, b) => a + b", "fn(a, b) { (a+b); }"},
+		{"if (a) { 1 } else { 2 }", "if (a) { 1; } else { 2; }"},
+		{"match (x) { 1 => true, _ => false }", "match (x) {1 => true, _ => false}"},
+		{"a\n+\nb", "(a+b)"},
+	}
+	for _, tt := range tests {
+		expr, err := ParseExpression(tt.input)
+		if err != nil {
+			t.Errorf("%q: unexpected error %s", tt.input, err)
+			continue
+		}
+		if expr.Node_String() != tt.want {
+			t.Errorf("%q: got %s, want %s", tt.input, expr.Node_String(), tt.want)
+		}
+	}
+}
+
+func TestParseExpressionErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string // the first error, with its position
+	}{
+		{"", "1:1: unexpected end of input, expected an expression"},
+		{"   ", "1:4: unexpected end of input, expected an expression"},
+		{"1 2", "1:3: unexpected \"2\" after the expression"},
+		{"a + b;", "1:6: unexpected \";\" after the expression"},
+		{"a + b; c", "1:6: unexpected \";\" after the expression"},
+		{"f(x) )", "1:6: unexpected \")\" after the expression"},
+		{"let x = 1", "1:1: expected an expression, got a let statement"},
+		{"return x", "1:1: expected an expression, got a return statement"},
+		{"a +", "1:4: unexpected end of input, expected an expression"},
+		{"price *\n  * qty", "2:3: unexpected \"*\", expected an expression"},
+		{"(a + b", "1:7: expected next token to be ), got EOF instead"},
+		{"if (a) { 1", "1:11: expected } to close the block opened at 1:8"},
+		{"f(a: 1, 2)", "1:10: positional argument follows keyword argument"},
+		{"99999999999999999999", "1:1: could not parse 99999999999999999999 as an integer"},
+	}
+	for _, tt := range tests {
+		expr, err := ParseExpression(tt.input)
+		if err == nil {
+			t.Errorf("%q: want error %q, got %s", tt.input, tt.want, expr.Node_String())
+			continue
+		}
+		errs, ok := err.(ErrorList)
+		if !ok {
+			t.Errorf("%q: error is a %T, want an ErrorList", tt.input, err)
+			continue
+		}
+		if expr != nil {
+			t.Errorf("%q: got an expression along with the error", tt.input)
+		}
+		if errs[0].Error() != tt.want {
+			t.Errorf("%q: want error %q, got %q", tt.input, tt.want, errs[0].Error())
+		}
+	}
+}
+
+func TestErrorList(t *testing.T) {
+	one := &Error{Pos: token.Position{Offset: 4, Line: 2, Column: 3}, Msg: "first"}
+	two := &Error{Pos: token.Position{Offset: 9, Line: 3, Column: 1}, Msg: "second"}
+	tests := []struct {
+		errs ErrorList
+		want string
+	}{
+		{nil, "no errors"},
+		{ErrorList{one}, "2:3: first"},
+		{ErrorList{one, two}, "2:3: first (and 1 more errors)"},
+	}
+	for _, tt := range tests {
+		if tt.errs.Error() != tt.want {
+			t.Errorf("got %q, want %q", tt.errs.Error(), tt.want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"io"
 	"monkey/ast"
 	"monkey/lexer"
 	"monkey/token"
@@ -13,6 +14,20 @@ type Parser struct {
 	currentToken token.Token  // The current token
 	peekToken    token.Token  // The next token
 
+	errors ErrorList // The errors encountered while parsing
+
+	// Set while parsing a match guard, where `=>` ends the guard instead of starting an arrow function
+	_noArrowFunctions bool
+
+	/** Span recording for package cst, see spans.go **/
+	_tokens []token.Token
+	_spans  []NodeSpan
+	_spanOf map[ast.Node]int
+
+	/** Tracing, see parser_tracing.go **/
+	_tracer     io.Writer
+	_traceDepth int
+
 	/** PRATT **/
 	_prefixParsingFunctions map[token.TokenType]prattPrefixParsingFuncntion
 	_infixParsingFunctins   map[token.TokenType]prattInfixParsingFunction
@@ -35,6 +50,8 @@ func New(lexer *lexer.Lexer) *Parser {
 	parser.addPrefixFn(parser.parseGroupedExpression, token.LPAREN)
 	parser.addPrefixFn(parser.parseIFExpression, token.IF)
 	parser.addPrefixFn(parser.parseFunctionLiteral, token.FUNCTION)
+	parser.addPrefixFn(parser.parseMatchExpression, token.MATCH)
+	parser.addPrefixFn(parser.parseMacroLiteral, token.MACRO)
 
 	parser.addInfixFn(parser.parseInfixExpression, token.PLUS)
 	parser.addInfixFn(parser.parseInfixExpression, token.MINUS)
@@ -45,12 +62,16 @@ func New(lexer *lexer.Lexer) *Parser {
 	parser.addInfixFn(parser.parseInfixExpression, token.LT)
 	parser.addInfixFn(parser.parseInfixExpression, token.GT)
 	parser.addInfixFn(parser.parseCallExpression, token.LPAREN)
+	parser.addInfixFn(parser.parsePipeExpression, token.PIPE)
 	return &parser
 }
 
 func (ps *Parser) advance() {
 	ps.currentToken = ps.peekToken
 	ps.peekToken = ps.lexer.GetNextToken()
+	if ps._tokens != nil {
+		ps._tokens = append(ps._tokens, ps.peekToken)
+	}
 }
 
 func (ps *Parser) ParseProgram() *ast.Program {
@@ -65,6 +86,24 @@ func (ps *Parser) ParseProgram() *ast.Program {
 	return &program
 }
 
+// Errors returns the errors encountered while parsing
+func (ps *Parser) Errors() ErrorList {
+	return ps.errors
+}
+
+// Helper function that records an error at the position of the current token
+func (ps *Parser) addError(format string, args ...interface{}) {
+	ps.errorAt(ps.currentToken.Pos, format, args...)
+}
+
+func (ps *Parser) errorAt(pos token.Position, format string, args ...interface{}) {
+	ps.errors = append(ps.errors, &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)})
+}
+
+func (ps *Parser) peekError(tokenType token.TokenType) {
+	ps.errorAt(ps.peekToken.Pos, "expected next token to be %s, got %s instead", tokenType, ps.peekToken.Type)
+}
+
 func (ps *Parser) peekTokenIs(tokenType token.TokenType) bool {
 	return ps.peekToken.Type == tokenType
 }
@@ -74,25 +113,41 @@ func (ps *Parser) currentTokenIs(tokenType token.TokenType) bool {
 }
 
 func (ps *Parser) parseStatement() ast.Statement {
+	defer ps.untrace(ps.trace("parseStatement"))
+	start := ps.tokenIndex()
+	var stmt ast.Statement
 	switch ps.currentToken.Type {
 	case token.LET:
-		return ps.parseLetStatement()
+		stmt = ps.parseLetStatement()
 	case token.RETURN:
-		return ps.parseReturnStatement()
+		stmt = ps.parseReturnStatement()
 	default:
-		return ps.parseExpressionStatement()
+		stmt = ps.parseExpressionStatement()
 	}
+	ps.recordSpan(stmt, start)
+	return stmt
 }
 
 /** Parse LET Statement **/
 func (ps *Parser) parseLetStatement() *ast.LET_Statement {
+	defer ps.untrace(ps.trace("parseLetStatement"))
 	stmt := ast.LET_Statement{Token: ps.currentToken}
-	if !ps.peekTokenIs(token.IDENTIFIER) {
+	if !ps.peekTokenIs(token.IDENTIFIER) && !ps.peekTokenIs(token.LBRACKET) && !ps.peekTokenIs(token.LBRACE) {
+		ps.peekError(token.IDENTIFIER)
 		return nil
 	}
 	ps.advance()
-	stmt.Name = &ast.Identifier{Token: ps.currentToken, Value: ps.currentToken.Literal}
+	stmt.Name = ps.parsePattern(false)
+	if stmt.Name == nil || !ps.checkBindings(ast.BoundIdentifiers(stmt.Name)) {
+		return nil
+	}
+	if ps.peekTokenIs(token.COLON) {
+		if stmt.Type = ps.parseAnnotation(); stmt.Type == nil {
+			return nil
+		}
+	}
 	if !ps.peekTokenIs(token.ASSIGN) {
+		ps.peekError(token.ASSIGN)
 		return nil
 	}
 	ps.advance()
@@ -106,6 +161,7 @@ func (ps *Parser) parseLetStatement() *ast.LET_Statement {
 
 /** Parse RETURN Statement **/
 func (ps *Parser) parseReturnStatement() *ast.RETURN_Statement {
+	defer ps.untrace(ps.trace("parseReturnStatement"))
 	stmt := ast.RETURN_Statement{Token: ps.currentToken}
 	ps.advance()
 	stmt.ReturnValue = ps._parseExpression(LOWEST)
@@ -117,7 +173,8 @@ func (ps *Parser) parseReturnStatement() *ast.RETURN_Statement {
 
 /** Parse EXPRESSION Statement **/
 func (ps *Parser) parseExpressionStatement() *ast.EXPRESSION_Statement {
-	stmt := ast.EXPRESSION_Statement{}
+	defer ps.untrace(ps.trace("parseExpressionStatement"))
+	stmt := ast.EXPRESSION_Statement{Token: ps.currentToken}
 	/** Magical function _parseExpression(int) **/
 	stmt.Expression = ps._parseExpression(LOWEST)
 	if ps.peekTokenIs(token.SEMICOLON) {
@@ -128,14 +185,20 @@ func (ps *Parser) parseExpressionStatement() *ast.EXPRESSION_Statement {
 
 /** Parse IDENTIFIER **/
 func (ps *Parser) parseIdentifier() ast.Expression {
-	return &ast.Identifier{Token: ps.currentToken, Value: ps.currentToken.Literal}
+	defer ps.untrace(ps.trace("parseIdentifier"))
+	ident := &ast.Identifier{Token: ps.currentToken, Value: ps.currentToken.Literal}
+	if ps.peekTokenIs(token.ARROW) && !ps._noArrowFunctions {
+		return ps.parseArrowFunction([]ast.Expression{ident})
+	}
+	return ident
 }
 
 func (ps *Parser) parseIntegerLiteral() ast.Expression {
+	defer ps.untrace(ps.trace("parseIntegerLiteral"))
 	lit := ast.INTEGER_Literal{Token: ps.currentToken}
 	val, err := strconv.ParseInt(ps.currentToken.Literal, 0, 64)
 	if err != nil {
-		fmt.Printf("Error in converting %s int to int64", ps.currentToken.Literal)
+		ps.addError("could not parse %s as an integer", ps.currentToken.Literal)
 		return nil
 	}
 	lit.Value = val
@@ -144,6 +207,7 @@ func (ps *Parser) parseIntegerLiteral() ast.Expression {
 
 /** Parse PrefixExpression **/
 func (ps *Parser) parsePrefixExpression() ast.Expression {
+	defer ps.untrace(ps.trace("parsePrefixExpression"))
 	expr := ast.PREFIX_Expression{Token: ps.currentToken}
 	ps.advance()
 	expr.Right = ps._parseExpression(PREFIX)
@@ -152,6 +216,7 @@ func (ps *Parser) parsePrefixExpression() ast.Expression {
 
 /** Parse Infix Expression **/
 func (ps *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
+	defer ps.untrace(ps.trace("parseInfixExpression"))
 	expr := ast.INFIX_Expression{
 		Token: ps.currentToken,
 		Left:  left,
@@ -164,35 +229,104 @@ func (ps *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 
 /** Parse Boolean **/
 func (ps *Parser) parseBoolean() ast.Expression {
+	defer ps.untrace(ps.trace("parseBoolean"))
 	return &ast.Boolean{Token: ps.currentToken, Value: ps.currentTokenIs(token.TRUE)}
 }
 
 /** Parse Grouped Expression **/
 func (ps *Parser) parseGroupedExpression() ast.Expression {
+	defer ps.untrace(ps.trace("parseGroupedExpression"))
+	arrowAllowed := !ps._noArrowFunctions
+	ps._noArrowFunctions = false
+	defer func() { ps._noArrowFunctions = !arrowAllowed }()
+	// `()` can only be the parameter list of an arrow function
+	if ps.peekTokenIs(token.RPAREN) {
+		ps.advance()
+		if !ps.peekTokenIs(token.ARROW) {
+			ps.peekError(token.ARROW)
+			return nil
+		}
+		if !arrowAllowed {
+			ps.addError("arrow function in a match guard must be parenthesized")
+			return nil
+		}
+		return ps.parseArrowFunction([]ast.Expression{})
+	}
 	ps.advance()
 	// parses the expression until it encounters a token whose precedence is == LOWEST e.g. ')'
-	expr := ps._parseExpression(LOWEST)
+	exprs := []ast.Expression{ps._parseExpression(LOWEST)}
+	for ps.peekTokenIs(token.COMMA) {
+		ps.advance()
+		ps.advance()
+		exprs = append(exprs, ps._parseExpression(LOWEST))
+	}
 	if !ps.peekTokenIs(token.RPAREN) {
+		ps.peekError(token.RPAREN)
 		return nil
 	}
 	ps.advance()
-	return expr
+	if arrowAllowed && ps.peekTokenIs(token.ARROW) {
+		return ps.parseArrowFunction(exprs)
+	}
+	if len(exprs) > 1 {
+		if !arrowAllowed && ps.peekTokenIs(token.ARROW) {
+			ps.addError("arrow function in a match guard must be parenthesized")
+			return nil
+		}
+		ps.peekError(token.ARROW)
+		return nil
+	}
+	return exprs[0]
+}
+
+/** Parse Arrow Function **/
+// desugars `(x, y) => x + y`, `x => x * 2` and `(x) => { ... }` into an ast.FunctionLiteral.
+// Called when the peek token is the '=>'; params are the expressions that preceded it
+func (ps *Parser) parseArrowFunction(params []ast.Expression) ast.Expression {
+	defer ps.untrace(ps.trace("parseArrowFunction"))
+	ps.advance()
+	lit := &ast.FunctionLiteral{Token: token.Token{Type: token.FUNCTION, Literal: "fn", Pos: ps.currentToken.Pos}}
+	lit.Parameters = []*ast.Parameter{}
+	for _, p := range params {
+		ident, ok := p.(*ast.Identifier)
+		if !ok {
+			ps.errorAt(lit.Token.Pos, "arrow function parameters must be plain names")
+			return nil
+		}
+		lit.Parameters = append(lit.Parameters, &ast.Parameter{Token: ident.Token, Name: ident})
+	}
+	if !ps.checkParameters(lit.Parameters) {
+		return nil
+	}
+	ps.advance()
+	if ps.currentTokenIs(token.LBRACE) {
+		lit.Body = ps.parseBlockStatement()
+		return lit
+	}
+	stmt := &ast.EXPRESSION_Statement{Token: ps.currentToken}
+	stmt.Expression = ps._parseExpression(LOWEST)
+	lit.Body = &ast.BlockStatement{Token: stmt.Token, Statemens: []ast.Statement{stmt}}
+	return lit
 }
 
 /** Parse IF Expression **/
 func (ps *Parser) parseIFExpression() ast.Expression {
+	defer ps.untrace(ps.trace("parseIFExpression"))
 	expr := ast.IF_Expression{Token: ps.currentToken}
 	if !ps.peekTokenIs(token.LPAREN) {
+		ps.peekError(token.LPAREN)
 		return nil
 	}
 	ps.advance()
 	ps.advance()
 	expr.Condition = ps._parseExpression(LOWEST)
 	if !ps.peekTokenIs(token.RPAREN) {
+		ps.peekError(token.RPAREN)
 		return nil
 	}
 	ps.advance()
 	if !ps.peekTokenIs(token.LBRACE) {
+		ps.peekError(token.LBRACE)
 		return nil
 	}
 	ps.advance()
@@ -200,8 +334,10 @@ func (ps *Parser) parseIFExpression() ast.Expression {
 	if ps.peekTokenIs(token.ELSE) {
 		ps.advance()
 		if !ps.peekTokenIs(token.LBRACE) {
+			ps.peekError(token.LBRACE)
 			return nil
 		}
+		ps.advance()
 		expr.Alternative = ps.parseBlockStatement()
 	}
 	return &expr
@@ -209,7 +345,9 @@ func (ps *Parser) parseIFExpression() ast.Expression {
 
 /** Parse Block Statement **/
 func (ps *Parser) parseBlockStatement() *ast.BlockStatement {
-	block := &ast.BlockStatement{}
+	defer ps.untrace(ps.trace("parseBlockStatement"))
+	start := ps.tokenIndex()
+	block := &ast.BlockStatement{Token: ps.currentToken}
 	block.Statemens = []ast.Statement{}
 	ps.advance()
 	for !ps.currentTokenIs(token.RBRACE) && !ps.currentTokenIs(token.EOF) {
@@ -217,17 +355,32 @@ func (ps *Parser) parseBlockStatement() *ast.BlockStatement {
 		block.Statemens = append(block.Statemens, stmt)
 		ps.advance()
 	}
+	if ps.currentTokenIs(token.EOF) {
+		ps.addError("expected } to close the block opened at %s", block.Token.Pos)
+	}
+	ps.recordSpan(block, start)
 	return block
 }
 
 func (ps *Parser) parseFunctionLiteral() ast.Expression {
+	defer ps.untrace(ps.trace("parseFunctionLiteral"))
 	lit := ast.FunctionLiteral{Token: ps.currentToken}
 	if !ps.peekTokenIs(token.LPAREN) {
+		ps.peekError(token.LPAREN)
 		return nil
 	}
 	ps.advance() // current token is LPAREN
 	lit.Parameters = ps.parseFunctionParameters()
+	if lit.Parameters == nil {
+		return nil
+	}
+	if ps.peekTokenIs(token.COLON) {
+		if lit.ReturnType = ps.parseAnnotation(); lit.ReturnType == nil {
+			return nil
+		}
+	}
 	if !ps.peekTokenIs(token.LBRACE) {
+		ps.peekError(token.LBRACE)
 		return nil
 	}
 	ps.advance()
@@ -235,57 +388,545 @@ func (ps *Parser) parseFunctionLiteral() ast.Expression {
 	return &lit
 }
 
-func (ps *Parser) parseFunctionParameters() []*ast.Identifier {
-	// called when current token is token.IDENT
-	identifiers := []*ast.Identifier{}
-	// checks is ps.peekToken is RPAREN -> This may be the case if there are no identifiers
+/** Parse MACRO Literal **/
+// parses `macro(a, b) { ... }`; the parameters are plain names, since the arguments they stand
+// for are pieces of code rather than values
+func (ps *Parser) parseMacroLiteral() ast.Expression {
+	defer ps.untrace(ps.trace("parseMacroLiteral"))
+	lit := &ast.MacroLiteral{Token: ps.currentToken, Parameters: []*ast.Identifier{}}
+	if !ps.peekTokenIs(token.LPAREN) {
+		ps.peekError(token.LPAREN)
+		return nil
+	}
+	ps.advance()
+	for !ps.peekTokenIs(token.RPAREN) {
+		if len(lit.Parameters) > 0 {
+			if !ps.peekTokenIs(token.COMMA) {
+				ps.peekError(token.COMMA)
+				return nil
+			}
+			ps.advance()
+		}
+		if !ps.peekTokenIs(token.IDENTIFIER) {
+			ps.peekError(token.IDENTIFIER)
+			return nil
+		}
+		ps.advance()
+		start := ps.tokenIndex()
+		param := &ast.Identifier{Token: ps.currentToken, Value: ps.currentToken.Literal}
+		ps.recordSpan(param, start)
+		lit.Parameters = append(lit.Parameters, param)
+	}
+	ps.advance()
+	if !ps.checkBindings(lit.Parameters) {
+		return nil
+	}
+	if !ps.peekTokenIs(token.LBRACE) {
+		ps.peekError(token.LBRACE)
+		return nil
+	}
+	ps.advance()
+	lit.Body = ps.parseBlockStatement()
+	return lit
+}
+
+/** Parse MATCH Expression **/
+func (ps *Parser) parseMatchExpression() ast.Expression {
+	defer ps.untrace(ps.trace("parseMatchExpression"))
+	expr := &ast.MATCH_Expression{Token: ps.currentToken}
+	if !ps.peekTokenIs(token.LPAREN) {
+		ps.peekError(token.LPAREN)
+		return nil
+	}
+	ps.advance()
+	ps.advance()
+	expr.Subject = ps._parseExpression(LOWEST)
+	if !ps.peekTokenIs(token.RPAREN) {
+		ps.peekError(token.RPAREN)
+		return nil
+	}
+	ps.advance()
+	if !ps.peekTokenIs(token.LBRACE) {
+		ps.peekError(token.LBRACE)
+		return nil
+	}
+	ps.advance()
+	expr.Arms = []*ast.MATCH_Arm{}
+	for !ps.peekTokenIs(token.RBRACE) {
+		ps.advance()
+		arm := ps.parseMatchArm()
+		if arm == nil {
+			return nil
+		}
+		expr.Arms = append(expr.Arms, arm)
+		if !ps.peekTokenIs(token.COMMA) {
+			break
+		}
+		ps.advance()
+	}
+	if !ps.peekTokenIs(token.RBRACE) {
+		ps.peekError(token.RBRACE)
+		return nil
+	}
+	ps.advance()
+	return expr
+}
+
+// parses `pattern => body` or `pattern if guard => body`
+func (ps *Parser) parseMatchArm() *ast.MATCH_Arm {
+	defer ps.untrace(ps.trace("parseMatchArm"))
+	arm := &ast.MATCH_Arm{Token: ps.currentToken}
+	start := ps.tokenIndex()
+	defer ps.recordSpan(arm, start)
+	arm.Pattern = ps.parsePattern(true)
+	if arm.Pattern == nil || !ps.checkBindings(ast.BoundIdentifiers(arm.Pattern)) {
+		return nil
+	}
+	if ps.peekTokenIs(token.IF) {
+		ps.advance()
+		ps.advance()
+		ps._noArrowFunctions = true
+		arm.Guard = ps._parseExpression(LOWEST)
+		ps._noArrowFunctions = false
+	}
+	if !ps.peekTokenIs(token.ARROW) {
+		ps.peekError(token.ARROW)
+		return nil
+	}
+	ps.advance()
+	ps.advance()
+	arm.Body = ps._parseExpression(LOWEST)
+	return arm
+}
+
+/** Parse PIPE Expression **/
+// desugars `x |> f(a)` into `f(x, a)` and `x |> f` into `f(x)`
+func (ps *Parser) parsePipeExpression(left ast.Expression) ast.Expression {
+	defer ps.untrace(ps.trace("parsePipeExpression"))
+	precedence := ps.currentPrecedence()
+	ps.advance()
+	right := ps._parseExpression(precedence)
+	if right == nil {
+		return nil
+	}
+	if call, ok := right.(*ast.CALL_Expression); ok {
+		call.Arguments = append([]ast.Expression{left}, call.Arguments...)
+		return call
+	}
+	return &ast.CALL_Expression{
+		Token:     token.Token{Type: token.LPAREN, Literal: "("},
+		Function:  right,
+		Arguments: []ast.Expression{left},
+	}
+}
+
+/** Parse Function Parameters **/
+// called when the current token is the '(' of the parameter list
+func (ps *Parser) parseFunctionParameters() []*ast.Parameter {
+	defer ps.untrace(ps.trace("parseFunctionParameters"))
+	params := []*ast.Parameter{}
+	// checks is ps.peekToken is RPAREN -> This may be the case if there are no parameters
 	if ps.peekTokenIs(token.RPAREN) {
 		ps.advance()
-		return identifiers
+		return params
 	}
 	ps.advance()
-	ident := &ast.Identifier{Token: ps.currentToken, Value: ps.currentToken.Literal}
-	identifiers = append(identifiers, ident)
+	param := ps.parseParameter()
+	if param == nil {
+		return nil
+	}
+	params = append(params, param)
 	for ps.peekTokenIs(token.COMMA) {
 		ps.advance()
 		ps.advance()
-		ident := &ast.Identifier{Token: ps.currentToken, Value: ps.currentToken.Literal}
-		identifiers = append(identifiers, ident)
+		param := ps.parseParameter()
+		if param == nil {
+			return nil
+		}
+		params = append(params, param)
 	}
 	if !ps.peekTokenIs(token.RPAREN) {
+		ps.peekError(token.RPAREN)
 		return nil
 	}
 	ps.advance()
-	return identifiers
+	if !ps.checkParameters(params) {
+		return nil
+	}
+	return params
+}
+
+// parses `name`, `pattern`, `pattern = default` or `...name`
+func (ps *Parser) parseParameter() *ast.Parameter {
+	defer ps.untrace(ps.trace("parseParameter"))
+	param := &ast.Parameter{Token: ps.currentToken}
+	start := ps.tokenIndex()
+	defer ps.recordSpan(param, start)
+	if ps.currentTokenIs(token.ELLIPSIS) {
+		param.Variadic = true
+		ps.advance()
+		if !ps.currentTokenIs(token.IDENTIFIER) {
+			ps.addError("expected rest parameter name, got %s instead", ps.currentToken.Type)
+			return nil
+		}
+	}
+	param.Name = ps.parsePattern(false)
+	if param.Name == nil {
+		return nil
+	}
+	if ps.peekTokenIs(token.COLON) {
+		if param.Type = ps.parseAnnotation(); param.Type == nil {
+			return nil
+		}
+	}
+	if ps.peekTokenIs(token.ASSIGN) {
+		if param.Variadic {
+			ps.addError("rest parameter %s cannot have a default value", param.Name.Node_String())
+			return nil
+		}
+		ps.advance()
+		ps.advance()
+		param.Default = ps._parseExpression(LOWEST)
+	}
+	return param
+}
+
+// checks that required parameters come first, the rest parameter comes last and that no name is repeated
+func (ps *Parser) checkParameters(params []*ast.Parameter) bool {
+	names := []*ast.Identifier{}
+	withDefault := ""
+	for i, p := range params {
+		names = append(names, ast.BoundIdentifiers(p.Name)...)
+		switch {
+		case p.Variadic && i != len(params)-1:
+			ps.errorAt(p.Token.Pos, "rest parameter %s must be the last parameter", p.Name.Node_String())
+			return false
+		case p.Default != nil:
+			withDefault = p.Name.Node_String()
+		case !p.Variadic && withDefault != "":
+			ps.errorAt(p.Token.Pos, "parameter %s without a default value follows parameter %s with a default value", p.Name.Node_String(), withDefault)
+			return false
+		}
+	}
+	return ps.checkBindings(names)
+}
+
+// checks that no name is bound twice by the same parameter list or pattern
+func (ps *Parser) checkBindings(names []*ast.Identifier) bool {
+	seen := map[string]bool{}
+	for _, id := range names {
+		if seen[id.Value] {
+			ps.errorAt(id.Token.Pos, "%s bound more than once", id.Value)
+			return false
+		}
+		seen[id.Value] = true
+	}
+	return true
+}
+
+/** Parse Pattern **/
+// parses the pattern starting at the current token: an identifier, `[a, b, ...rest]` or `{key, key: pattern}`.
+// Refutable patterns, as used by match arms, may also be literals or the `_` wildcard
+func (ps *Parser) parsePattern(refutable bool) ast.Pattern {
+	defer ps.untrace(ps.trace("parsePattern"))
+	start := ps.tokenIndex()
+	pattern := ps.parsePatternNode(refutable)
+	ps.recordSpan(pattern, start)
+	return pattern
+}
+
+func (ps *Parser) parsePatternNode(refutable bool) ast.Pattern {
+	switch ps.currentToken.Type {
+	case token.IDENTIFIER:
+		if refutable && ps.currentToken.Literal == "_" {
+			return &ast.WILDCARD_Pattern{Token: ps.currentToken}
+		}
+		return &ast.Identifier{Token: ps.currentToken, Value: ps.currentToken.Literal}
+	case token.LBRACKET:
+		return ps.parseArrayPattern(refutable)
+	case token.LBRACE:
+		return ps.parseHashPattern(refutable)
+	case token.INT, token.TRUE, token.FALSE, token.MINUS:
+		if refutable {
+			return ps.parseLiteralPattern()
+		}
+		fallthrough
+	default:
+		ps.addError("expected a name or a pattern, got %s instead", ps.currentToken.Type)
+		return nil
+	}
+}
+
+func (ps *Parser) parseLiteralPattern() ast.Pattern {
+	defer ps.untrace(ps.trace("parseLiteralPattern"))
+	pattern := &ast.LITERAL_Pattern{Token: ps.currentToken}
+	switch ps.currentToken.Type {
+	case token.MINUS:
+		if !ps.peekTokenIs(token.INT) {
+			ps.peekError(token.INT)
+			return nil
+		}
+		pattern.Value = ps.parsePrefixExpression()
+	case token.INT:
+		pattern.Value = ps.parseIntegerLiteral()
+	default:
+		pattern.Value = ps.parseBoolean()
+	}
+	if pattern.Value == nil {
+		return nil
+	}
+	return pattern
+}
+
+func (ps *Parser) parseArrayPattern(refutable bool) ast.Pattern {
+	defer ps.untrace(ps.trace("parseArrayPattern"))
+	pattern := &ast.ARRAY_Pattern{Token: ps.currentToken}
+	pattern.Elements = []ast.Pattern{}
+	if ps.peekTokenIs(token.RBRACKET) {
+		ps.advance()
+		return pattern
+	}
+	for {
+		ps.advance()
+		if ps.currentTokenIs(token.ELLIPSIS) {
+			if !ps.peekTokenIs(token.IDENTIFIER) {
+				ps.peekError(token.IDENTIFIER)
+				return nil
+			}
+			ps.advance()
+			pattern.Rest = &ast.Identifier{Token: ps.currentToken, Value: ps.currentToken.Literal}
+			break
+		}
+		el := ps.parsePattern(refutable)
+		if el == nil {
+			return nil
+		}
+		pattern.Elements = append(pattern.Elements, el)
+		if !ps.peekTokenIs(token.COMMA) {
+			break
+		}
+		ps.advance()
+	}
+	if !ps.peekTokenIs(token.RBRACKET) {
+		ps.peekError(token.RBRACKET)
+		return nil
+	}
+	ps.advance()
+	return pattern
+}
+
+func (ps *Parser) parseHashPattern(refutable bool) ast.Pattern {
+	defer ps.untrace(ps.trace("parseHashPattern"))
+	pattern := &ast.HASH_Pattern{Token: ps.currentToken}
+	pattern.Entries = []*ast.HASH_PatternEntry{}
+	if ps.peekTokenIs(token.RBRACE) {
+		ps.advance()
+		return pattern
+	}
+	for {
+		if !ps.peekTokenIs(token.IDENTIFIER) {
+			ps.peekError(token.IDENTIFIER)
+			return nil
+		}
+		ps.advance()
+		entry := &ast.HASH_PatternEntry{Token: ps.currentToken}
+		start := ps.tokenIndex()
+		entry.Key = &ast.Identifier{Token: ps.currentToken, Value: ps.currentToken.Literal}
+		entry.Value = entry.Key
+		if ps.peekTokenIs(token.COLON) {
+			ps.advance()
+			ps.advance()
+			entry.Value = ps.parsePattern(refutable)
+			if entry.Value == nil {
+				return nil
+			}
+		}
+		ps.recordSpan(entry, start)
+		pattern.Entries = append(pattern.Entries, entry)
+		if !ps.peekTokenIs(token.COMMA) {
+			break
+		}
+		ps.advance()
+	}
+	if !ps.peekTokenIs(token.RBRACE) {
+		ps.peekError(token.RBRACE)
+		return nil
+	}
+	ps.advance()
+	return pattern
+}
+
+/** Parse Type Annotations **/
+// called when the current token is the first token of the type
+func (ps *Parser) parseType() ast.TypeExpr {
+	defer ps.untrace(ps.trace("parseType"))
+	start := ps.tokenIndex()
+	typ := ps.parseTypeNode()
+	ps.recordSpan(typ, start)
+	return typ
+}
+
+func (ps *Parser) parseTypeNode() ast.TypeExpr {
+	switch ps.currentToken.Type {
+	case token.IDENTIFIER:
+		return &ast.TYPE_Name{Token: ps.currentToken, Value: ps.currentToken.Literal}
+	case token.LBRACKET:
+		typ := &ast.ARRAY_Type{Token: ps.currentToken}
+		ps.advance()
+		if typ.Element = ps.parseType(); typ.Element == nil {
+			return nil
+		}
+		if !ps.peekTokenIs(token.RBRACKET) {
+			ps.peekError(token.RBRACKET)
+			return nil
+		}
+		ps.advance()
+		return typ
+	case token.LBRACE:
+		typ := &ast.HASH_Type{Token: ps.currentToken}
+		ps.advance()
+		if typ.Key = ps.parseType(); typ.Key == nil {
+			return nil
+		}
+		if !ps.peekTokenIs(token.COLON) {
+			ps.peekError(token.COLON)
+			return nil
+		}
+		ps.advance()
+		ps.advance()
+		if typ.Value = ps.parseType(); typ.Value == nil {
+			return nil
+		}
+		if !ps.peekTokenIs(token.RBRACE) {
+			ps.peekError(token.RBRACE)
+			return nil
+		}
+		ps.advance()
+		return typ
+	case token.FUNCTION:
+		return ps.parseFunctionType()
+	}
+	ps.addError("expected a type, got %s instead", describeToken(ps.currentToken))
+	return nil
+}
+
+// e.g. `fn(int, bool): int`
+func (ps *Parser) parseFunctionType() ast.TypeExpr {
+	defer ps.untrace(ps.trace("parseFunctionType"))
+	typ := &ast.FUNCTION_Type{Token: ps.currentToken, Parameters: []ast.TypeExpr{}}
+	if !ps.peekTokenIs(token.LPAREN) {
+		ps.peekError(token.LPAREN)
+		return nil
+	}
+	ps.advance()
+	for !ps.peekTokenIs(token.RPAREN) {
+		if len(typ.Parameters) > 0 {
+			if !ps.peekTokenIs(token.COMMA) {
+				ps.peekError(token.RPAREN)
+				return nil
+			}
+			ps.advance()
+		}
+		ps.advance()
+		param := ps.parseType()
+		if param == nil {
+			return nil
+		}
+		typ.Parameters = append(typ.Parameters, param)
+	}
+	ps.advance()
+	if typ.Return = ps.parseAnnotation(); typ.Return == nil {
+		if !ps.peekTokenIs(token.COLON) {
+			ps.peekError(token.COLON)
+		}
+		return nil
+	}
+	return typ
+}
+
+// Helper function that parses the `: type` after the current token if there is one, and returns nil otherwise
+func (ps *Parser) parseAnnotation() ast.TypeExpr {
+	if !ps.peekTokenIs(token.COLON) {
+		return nil
+	}
+	ps.advance()
+	ps.advance()
+	return ps.parseType()
 }
 
 /** Parse CALL Expression **/
 func (ps *Parser) parseCallExpression(function ast.Expression) ast.Expression {
+	defer ps.untrace(ps.trace("parseCallExpression"))
 	expr := &ast.CALL_Expression{Token: ps.currentToken, Function: function}
 	expr.Arguments = ps.parseCallArguments()
 	return expr
 }
 
 func (ps *Parser) parseCallArguments() []ast.Expression {
+	defer ps.untrace(ps.trace("parseCallArguments"))
+	noArrowFunctions := ps._noArrowFunctions
+	ps._noArrowFunctions = false
+	defer func() { ps._noArrowFunctions = noArrowFunctions }()
 	args := []ast.Expression{}
 	if ps.peekTokenIs(token.RPAREN) {
 		ps.advance()
 		return args
 	}
 	ps.advance()
-	args = append(args, ps._parseExpression(LOWEST))
+	args = append(args, ps.parseCallArgument())
 	for ps.peekTokenIs(token.COMMA) {
 		ps.advance()
 		ps.advance()
-		args = append(args, ps._parseExpression(LOWEST))
+		args = append(args, ps.parseCallArgument())
 	}
 	if !ps.peekTokenIs(token.RPAREN) {
+		ps.peekError(token.RPAREN)
 		return nil
 	}
 	ps.advance()
+	if !ps.checkCallArguments(args) {
+		return nil
+	}
 	return args
 }
 
+// parses a positional argument or a `name: value` keyword argument
+func (ps *Parser) parseCallArgument() ast.Expression {
+	defer ps.untrace(ps.trace("parseCallArgument"))
+	if !ps.currentTokenIs(token.IDENTIFIER) || !ps.peekTokenIs(token.COLON) {
+		return ps._parseExpression(LOWEST)
+	}
+	arg := &ast.KEYWORD_Argument{Token: ps.currentToken}
+	start := ps.tokenIndex()
+	defer ps.recordSpan(arg, start)
+	arg.Name = &ast.Identifier{Token: ps.currentToken, Value: ps.currentToken.Literal}
+	ps.advance()
+	ps.advance()
+	arg.Value = ps._parseExpression(LOWEST)
+	return arg
+}
+
+// checks that keyword arguments come after the positional ones and are not repeated
+func (ps *Parser) checkCallArguments(args []ast.Expression) bool {
+	seen := map[string]bool{}
+	for _, arg := range args {
+		kw, ok := arg.(*ast.KEYWORD_Argument)
+		if !ok {
+			if len(seen) > 0 {
+				ps.addError("positional argument follows keyword argument")
+				return false
+			}
+			continue
+		}
+		if seen[kw.Name.Value] {
+			ps.errorAt(kw.Token.Pos, "keyword argument %s repeated", kw.Name.Value)
+			return false
+		}
+		seen[kw.Name.Value] = true
+	}
+	return true
+}
+
 /** PRATT Parser **/
 type (
 	prattPrefixParsingFuncntion func() ast.Expression
@@ -306,6 +947,7 @@ const (
 	LOWEST
 	EQUALS
 	LESS_GREATER
+	PIPE // binds looser than arithmetic but tighter than comparisons, as in Elixir
 	SUM
 	PRODUCT
 	PREFIX
@@ -313,6 +955,7 @@ const (
 )
 
 var precedences = map[token.TokenType]int{
+	token.PIPE:     PIPE,
 	token.EQ:       EQUALS,
 	token.NOT_EQ:   EQUALS,
 	token.LT:       LESS_GREATER,
@@ -325,11 +968,15 @@ var precedences = map[token.TokenType]int{
 }
 
 func (ps *Parser) _parseExpression(bindingPower int) ast.Expression {
+	defer ps.untrace(ps.traceExpression(bindingPower))
 	prefixFn := ps._prefixParsingFunctions[ps.currentToken.Type]
 	if prefixFn == nil {
+		ps.addError("unexpected %s, expected an expression", describeToken(ps.currentToken))
 		return nil
 	}
+	start := ps.tokenIndex()
 	leftExpr := prefixFn()
+	ps.recordSpan(leftExpr, start)
 	for bindingPower < ps.peekPrecedence() {
 		infixFn := ps._infixParsingFunctins[ps.peekToken.Type]
 		if infixFn == nil {
@@ -337,10 +984,19 @@ func (ps *Parser) _parseExpression(bindingPower int) ast.Expression {
 		}
 		ps.advance()
 		leftExpr = infixFn(leftExpr)
+		ps.recordSpan(leftExpr, start)
 	}
 	return leftExpr
 }
 
+// Precedence returns the binding power of an infix operator, or LOWEST if the token is not one
+func Precedence(tokenType token.TokenType) int {
+	if p, ok := precedences[tokenType]; ok {
+		return p
+	}
+	return LOWEST
+}
+
 func (ps *Parser) peekPrecedence() int {
 	if p, ok := precedences[ps.peekToken.Type]; ok {
 		return p
@@ -354,3 +1010,11 @@ func (ps *Parser) currentPrecedence() int {
 	}
 	return LOWEST
 }
+
+// Helper function that describes a token for error messages
+func describeToken(tok token.Token) string {
+	if tok.Type == token.EOF {
+		return "end of input"
+	}
+	return fmt.Sprintf("%q", tok.Literal)
+}
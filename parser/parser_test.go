@@ -0,0 +1,434 @@
+package parser
+
+import (
+	"fmt"
+	"monkey/ast"
+	"monkey/lexer"
+	"strings"
+	"testing"
+)
+
+// Helper function that parses input and fails the test on a parse error
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+	ps := New(lexer.New(input))
+	program := ps.ParseProgram()
+	if errs := ps.Errors(); len(errs) > 0 {
+		t.Fatalf("parsing %q: %s", input, errs)
+	}
+	return program
+}
+
+// Helper function that parses input and returns the parse errors
+func parseErrors(input string) ErrorList {
+	ps := New(lexer.New(input))
+	ps.ParseProgram()
+	return ps.Errors()
+}
+
+// Helper function that returns the expression of the only statement in program
+func onlyExpression(t *testing.T, program *ast.Program) ast.Expression {
+	t.Helper()
+	if len(program.Statements) != 1 {
+		t.Fatalf("want 1 statement, got %d", len(program.Statements))
+	}
+	stmt, ok := program.Statements[0].(*ast.EXPRESSION_Statement)
+	if !ok {
+		t.Fatalf("want an expression statement, got %T", program.Statements[0])
+	}
+	return stmt.Expression
+}
+
+func TestParameters(t *testing.T) {
+	tests := []struct {
+		input    string
+		names    []string
+		defaults []string // the default of each parameter as Node_String, "" if it has none
+		variadic []bool
+	}{
+		{"fn() {}", []string{}, []string{}, []bool{}},
+		{"fn(a, b) {}", []string{"a", "b"}, []string{"", ""}, []bool{false, false}},
+		{"fn(a, b = 2) {}", []string{"a", "b"}, []string{"", "2"}, []bool{false, false}},
+		{"fn(a = 1 + 2, b = a) {}", []string{"a", "b"}, []string{"(1+2)", "a"}, []bool{false, false}},
+		{"fn(...rest) {}", []string{"rest"}, []string{""}, []bool{true}},
+		{"fn(a, b = 2, ...rest) {}", []string{"a", "b", "rest"}, []string{"", "2", ""}, []bool{false, false, true}},
+	}
+	for _, tt := range tests {
+		fl, ok := onlyExpression(t, parse(t, tt.input)).(*ast.FunctionLiteral)
+		if !ok {
+			t.Fatalf("%q: want a function literal", tt.input)
+		}
+		if len(fl.Parameters) != len(tt.names) {
+			t.Fatalf("%q: want %d parameters, got %d", tt.input, len(tt.names), len(fl.Parameters))
+		}
+		for i, p := range fl.Parameters {
+			if p.Name.Node_String() != tt.names[i] {
+				t.Errorf("%q: parameter %d is named %s, want %s", tt.input, i, p.Name.Node_String(), tt.names[i])
+			}
+			def := ""
+			if p.Default != nil {
+				def = p.Default.Node_String()
+			}
+			if def != tt.defaults[i] {
+				t.Errorf("%q: parameter %d defaults to %q, want %q", tt.input, i, def, tt.defaults[i])
+			}
+			if p.Variadic != tt.variadic[i] {
+				t.Errorf("%q: parameter %d variadic is %t, want %t", tt.input, i, p.Variadic, tt.variadic[i])
+			}
+		}
+	}
+}
+
+func TestCallArguments(t *testing.T) {
+	tests := []struct {
+		input    string
+		keywords []string // the name of each argument passed by keyword, "" for a positional one
+	}{
+		{"f()", []string{}},
+		{"f(1, 2)", []string{"", ""}},
+		{"f(1, b: 3)", []string{"", "b"}},
+		{"f(a: 1, b: 2)", []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		ce, ok := onlyExpression(t, parse(t, tt.input)).(*ast.CALL_Expression)
+		if !ok {
+			t.Fatalf("%q: want a call expression", tt.input)
+		}
+		if len(ce.Arguments) != len(tt.keywords) {
+			t.Fatalf("%q: want %d arguments, got %d", tt.input, len(tt.keywords), len(ce.Arguments))
+		}
+		for i, arg := range ce.Arguments {
+			name := ""
+			if kw, ok := arg.(*ast.KEYWORD_Argument); ok {
+				name = kw.Name.Value
+			}
+			if name != tt.keywords[i] {
+				t.Errorf("%q: argument %d has keyword %q, want %q", tt.input, i, name, tt.keywords[i])
+			}
+		}
+	}
+}
+
+func TestParameterErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"fn(a = 1, b) {}", "parameter b without a default value follows parameter a with a default value"},
+		{"fn(a, a) {}", "a bound more than once"},
+		{"fn(...a, b) {}", "rest parameter a must be the last parameter"},
+		{"fn(...a = 1) {}", "rest parameter a cannot have a default value"},
+		{"fn(1) {}", "expected a name or a pattern, got INT instead"},
+		{"fn(a b) {}", "expected next token to be ), got IDENTIFIER instead"},
+		{"f(a: 1, 2)", "positional argument follows keyword argument"},
+		{"f(a: 1, a: 2)", "keyword argument a repeated"},
+		{"f(1 2)", "expected next token to be ), got INT instead"},
+	}
+	for _, tt := range tests {
+		errs := parseErrors(tt.input)
+		if len(errs) == 0 {
+			t.Errorf("%q: want error %q, got none", tt.input, tt.want)
+			continue
+		}
+		if errs[0].Msg != tt.want {
+			t.Errorf("%q: want error %q, got %q", tt.input, tt.want, errs[0].Msg)
+		}
+	}
+}
+
+func TestPatterns(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string // the pattern as Node_String
+		names []string
+	}{
+		{"let x = xs;", "x", []string{"x"}},
+		{"let [] = xs;", "[]", []string{}},
+		{"let [head, ...tail] = xs;", "[head, ...tail]", []string{"head", "tail"}},
+		{"let [a, [b, c], ...rest] = xs;", "[a, [b, c], ...rest]", []string{"a", "b", "c", "rest"}},
+		{"let [...all] = xs;", "[...all]", []string{"all"}},
+		{"let {name, age: years} = person;", "{name, age: years}", []string{"name", "years"}},
+		{"let {point: [x, y], tags: {first}} = shape;", "{point: [x, y], tags: {first}}", []string{"x", "y", "first"}},
+		{"let {} = x;", "{}", []string{}},
+	}
+	for _, tt := range tests {
+		program := parse(t, tt.input)
+		stmt, ok := program.Statements[0].(*ast.LET_Statement)
+		if !ok {
+			t.Fatalf("%q: want a let statement, got %T", tt.input, program.Statements[0])
+		}
+		if stmt.Name.Node_String() != tt.want {
+			t.Errorf("%q: pattern is %s, want %s", tt.input, stmt.Name.Node_String(), tt.want)
+		}
+		names := []string{}
+		for _, id := range ast.BoundIdentifiers(stmt.Name) {
+			names = append(names, id.Value)
+		}
+		if strings.Join(names, " ") != strings.Join(tt.names, " ") {
+			t.Errorf("%q: binds %v, want %v", tt.input, names, tt.names)
+		}
+	}
+}
+
+func TestPatternParameters(t *testing.T) {
+	fl, ok := onlyExpression(t, parse(t, "fn([a, ...b], {c, d: e} = x, ...rest) {}")).(*ast.FunctionLiteral)
+	if !ok {
+		t.Fatalf("want a function literal")
+	}
+	want := []string{"[a, ...b]", "{c, d: e} = x", "...rest"}
+	for i, p := range fl.Parameters {
+		if p.Node_String() != want[i] {
+			t.Errorf("parameter %d is %s, want %s", i, p.Node_String(), want[i])
+		}
+	}
+}
+
+func TestPatternErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"let [a, a] = xs;", "a bound more than once"},
+		{"let [a, {b: a}] = xs;", "a bound more than once"},
+		{"let [...rest, a] = xs;", "expected next token to be ], got , instead"},
+		{"let [...[a]] = xs;", "expected next token to be IDENTIFIER, got [ instead"},
+		{"let [1] = xs;", "expected a name or a pattern, got INT instead"},
+		{"let {1: a} = x;", "expected next token to be IDENTIFIER, got INT instead"},
+		{"let {a: 1} = x;", "expected a name or a pattern, got INT instead"},
+		{"let [a b] = xs;", "expected next token to be ], got IDENTIFIER instead"},
+		{"fn([a], [a]) {}", "a bound more than once"},
+		{"fn(...[a]) {}", "expected rest parameter name, got [ instead"},
+		{"fn(a, [b] = 1, c) {}", "parameter c without a default value follows parameter [b] with a default value"},
+	}
+	for _, tt := range tests {
+		errs := parseErrors(tt.input)
+		if len(errs) == 0 {
+			t.Errorf("%q: want error %q, got none", tt.input, tt.want)
+			continue
+		}
+		if errs[0].Msg != tt.want {
+			t.Errorf("%q: want error %q, got %q", tt.input, tt.want, errs[0].Msg)
+		}
+	}
+}
+
+func TestMatchExpression(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string // the match expression as Node_String
+	}{
+		{"match (x) {}", "match (x) {}"},
+		{"match (x) { 1 => 10, -1 => 20, true => 30, _ => 40 }", "match (x) {1 => 10, -1 => 20, true => 30, _ => 40}"},
+		{"match (x) { n if n > 0 => n, n => -n, }", "match (x) {n if (n>0) => n, n => (-n)}"},
+		{"match (xs) { [] => 0, [1, _] => 1, [head, ...tail] => head }", "match (xs) {[] => 0, [1, _] => 1, [head, ...tail] => head}"},
+		{"match (p) { {kind: 1, size} => size, {kind: [_, k]} if k => 0 }", "match (p) {{kind: 1, size} => size, {kind: [_, k]} if k => 0}"},
+		{"match (match (x) { _ => x }) { y => y }", "match (match (x) {_ => x}) {y => y}"},
+	}
+	for _, tt := range tests {
+		me, ok := onlyExpression(t, parse(t, tt.input)).(*ast.MATCH_Expression)
+		if !ok {
+			t.Fatalf("%q: want a match expression", tt.input)
+		}
+		if me.Node_String() != tt.want {
+			t.Errorf("%q: got %s, want %s", tt.input, me.Node_String(), tt.want)
+		}
+	}
+}
+
+func TestMatchPatternKinds(t *testing.T) {
+	me := onlyExpression(t, parse(t, "match (x) { _ => 0, 1 => 1, -2 => 2, false => 3, y if y => 4 }")).(*ast.MATCH_Expression)
+	kinds := []string{"*ast.WILDCARD_Pattern", "*ast.LITERAL_Pattern", "*ast.LITERAL_Pattern", "*ast.LITERAL_Pattern", "*ast.Identifier"}
+	for i, arm := range me.Arms {
+		if got := fmt.Sprintf("%T", arm.Pattern); got != kinds[i] {
+			t.Errorf("arm %d: pattern is %s, want %s", i, got, kinds[i])
+		}
+	}
+	if me.Arms[4].Guard == nil || me.Arms[3].Guard != nil {
+		t.Errorf("only the last arm has a guard")
+	}
+}
+
+func TestMatchErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"match x { _ => 1 }", "expected next token to be (, got IDENTIFIER instead"},
+		{"match (x) _ => 1", "expected next token to be {, got IDENTIFIER instead"},
+		{"match (x) { _ 1 }", "expected next token to be =>, got INT instead"},
+		{"match (x) { y if y 1 }", "expected next token to be =>, got INT instead"},
+		{"match (x) { _ => 1 _ => 2 }", "expected next token to be }, got IDENTIFIER instead"},
+		{"match (x) { -true => 1 }", "expected next token to be INT, got TRUE instead"},
+		{"match (x) { [a, a] => 1 }", "a bound more than once"},
+		{"match (x) { (a) => 1 }", "expected a name or a pattern, got ( instead"},
+		// only match arms take refutable patterns
+		{"let [1, b] = xs;", "expected a name or a pattern, got INT instead"},
+		{"fn(true) {}", "expected a name or a pattern, got TRUE instead"},
+	}
+	for _, tt := range tests {
+		errs := parseErrors(tt.input)
+		if len(errs) == 0 {
+			t.Errorf("%q: want error %q, got none", tt.input, tt.want)
+			continue
+		}
+		if errs[0].Msg != tt.want {
+			t.Errorf("%q: want error %q, got %q", tt.input, tt.want, errs[0].Msg)
+		}
+	}
+}
+
+func TestPipeAndArrowFunctions(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string // the desugared expression as Node_String
+	}{
+		{"x |> f", "f(x)"},
+		{"x |> f(a)", "f(x, a)"},
+		{"x |> f(a, b: 1)", "f(x, a, b: 1)"},
+		{"x |> f |> g(1)", "g(f(x), 1)"},
+		{"x + 1 |> f", "f((x+1))"},
+		{"x |> f < y", "(f(x)<y)"},
+		{"x => x * 2", "fn(x) { (x*2); }"},
+		{"(x, y) => x + y", "fn(x, y) { (x+y); }"},
+		{"() => 1", "fn() { 1; }"},
+		{"(x) => { let y = x; y }", "fn(x) { let y = x; y; }"},
+		{"xs |> map(x => x + 1)", "map(xs, fn(x) { (x+1); })"},
+		{"(x)", "x"},
+		{"match (x) { n if n > 0 => n }", "match (x) {n if (n>0) => n}"},
+		// in a guard `=>` ends the guard, the body may still be an arrow function
+		{"match (x) { n if m => m }", "match (x) {n if m => m}"},
+		{"match (x) { n if (n) => n }", "match (x) {n if n => n}"},
+		{"match (x) { n if n => m => m }", "match (x) {n if n => fn(m) { m; }}"},
+		// an arrow function can still appear inside a call or parentheses in a guard
+		{"match (x) { n if any(m => m) => n }", "match (x) {n if any(fn(m) { m; }) => n}"},
+		{"match (x) { n if (m => m)(n) => n }", "match (x) {n if fn(m) { m; }(n) => n}"},
+	}
+	for _, tt := range tests {
+		got := onlyExpression(t, parse(t, tt.input))
+		if got.Node_String() != tt.want {
+			t.Errorf("%q: got %s, want %s", tt.input, got.Node_String(), tt.want)
+		}
+	}
+}
+
+func TestArrowFunctionErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"(x, 1) => x", "arrow function parameters must be plain names"},
+		{"(x + 1) => x", "arrow function parameters must be plain names"},
+		{"(x, x) => x", "x bound more than once"},
+		{"(x, y)", "expected next token to be =>, got EOF instead"},
+		{"()", "expected next token to be =>, got EOF instead"},
+		// in a guard `=>` ends the guard instead of starting an arrow function
+		{"match (x) { n if () => n => n }", "arrow function in a match guard must be parenthesized"},
+		{"match (x) { n if (a, b) => a => n }", "arrow function in a match guard must be parenthesized"},
+	}
+	for _, tt := range tests {
+		errs := parseErrors(tt.input)
+		if len(errs) == 0 {
+			t.Errorf("%q: want error %q, got none", tt.input, tt.want)
+			continue
+		}
+		if errs[0].Msg != tt.want {
+			t.Errorf("%q: want error %q, got %q", tt.input, tt.want, errs[0].Msg)
+		}
+	}
+}
+
+func TestPositions(t *testing.T) {
+	program := parse(t, "let x = 1;\nlet f = fn(a) {\n  a + x\n};")
+	let := program.Statements[1].(*ast.LET_Statement)
+	if got := let.Token.Pos; got.Line != 2 || got.Column != 1 || got.Offset != 11 {
+		t.Errorf("second let is at %+v, want line 2, column 1, offset 11", got)
+	}
+	fl := let.Value.(*ast.FunctionLiteral)
+	if got := fl.Token.Pos.String(); got != "2:9" {
+		t.Errorf("fn is at %s, want 2:9", got)
+	}
+	infix := fl.Body.Statemens[0].(*ast.EXPRESSION_Statement).Expression.(*ast.INFIX_Expression)
+	if got := infix.Token.Pos.String(); got != "3:5" {
+		t.Errorf("+ is at %s, want 3:5", got)
+	}
+	if got := infix.Right.(*ast.Identifier).Token.Pos.String(); got != "3:7" {
+		t.Errorf("x is at %s, want 3:7", got)
+	}
+}
+
+func TestPositionedErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"let = 1;", "1:5: expected next token to be IDENTIFIER, got = instead"},
+		{"let x 1;", "1:7: expected next token to be =, got INT instead"},
+		{"let x = 1;\nif (x { 1 }", "2:7: expected next token to be ), got { instead"},
+		{"if (x) { 1 } else 2", "1:19: expected next token to be {, got INT instead"},
+		{"fn(a) {\n  a +\n", "3:1: unexpected end of input, expected an expression"},
+		{"fn(a = 1, b) {}", "1:11: parameter b without a default value follows parameter a with a default value"},
+		{"let x = 1 & 2;", "1:11: unexpected \"&\", expected an expression"},
+		{"let [a, b, a] = xs;", "1:12: a bound more than once"},
+		{"f(a: 1, a: 2)", "1:9: keyword argument a repeated"},
+	}
+	for _, tt := range tests {
+		errs := parseErrors(tt.input)
+		if len(errs) == 0 {
+			t.Errorf("%q: want error %q, got none", tt.input, tt.want)
+			continue
+		}
+		if errs[0].Error() != tt.want {
+			t.Errorf("%q: want error %q, got %q", tt.input, tt.want, errs[0].Error())
+		}
+	}
+}
+
+func TestTypeAnnotations(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"let x: int = 5;", "let x: int = 5;"},
+		{"let xs: [bool] = ys;", "let xs: [bool] = ys;"},
+		{"let ages: {string: [int]} = h;", "let ages: {string: [int]} = h;"},
+		{"let f: fn(int, bool): fn(): int = g;", "let f: fn(int, bool): fn(): int = g;"},
+		{"let [a, b]: [int] = xs;", "let [a, b]: [int] = xs;"},
+		{"fn(a: int, b: string): bool { a }", "fn(a: int, b: string): bool { a; };"},
+		{"fn(a: int = 1, ...rest: [int]) { a }", "fn(a: int = 1, ...rest: [int]) { a; };"},
+		{"fn(): [int] {}", "fn(): [int] { };"},
+	}
+	for _, tt := range tests {
+		program := parse(t, tt.input)
+		if got := strings.TrimSuffix(program.Node_String(), "\n"); got != tt.want {
+			t.Errorf("%q: got %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestTypeAnnotationErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"let x: = 5;", `expected a type, got "=" instead`},
+		{"let x: 5 = 5;", `expected a type, got "5" instead`},
+		{"let x: [int = 5;", "expected next token to be ], got = instead"},
+		{"let x: {string int} = h;", "expected next token to be :, got IDENTIFIER instead"},
+		{"let x: {string: int = h;", "expected next token to be }, got = instead"},
+		{"let f: fn int = g;", "expected next token to be (, got IDENTIFIER instead"},
+		{"let f: fn(int bool): int = g;", "expected next token to be ), got IDENTIFIER instead"},
+		{"let f: fn(int) = g;", "expected next token to be :, got = instead"},
+		{"fn(a:) { a }", `expected a type, got ")" instead`},
+		{"fn(a): { a }", "expected next token to be :, got } instead"},
+	}
+	for _, tt := range tests {
+		errs := parseErrors(tt.input)
+		if len(errs) == 0 {
+			t.Errorf("%q: want error %q, got none", tt.input, tt.want)
+			continue
+		}
+		if errs[0].Msg != tt.want {
+			t.Errorf("%q: want error %q, got %q", tt.input, tt.want, errs[0].Msg)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"monkey/token"
+	"strings"
+)
+
+// SetTrace makes the parser log the entry and exit of every parse function to w,
+// indented by recursion depth. A nil writer turns tracing off.
+func (ps *Parser) SetTrace(w io.Writer) {
+	ps._tracer = w
+	ps._traceDepth = 0
+}
+
+// Usage: defer ps.untrace(ps.trace("parseSomething"))
+func (ps *Parser) trace(name string) string {
+	if ps._tracer == nil {
+		return name
+	}
+	ps.tracePrint("BEGIN " + name)
+	ps._traceDepth++
+	return name
+}
+
+// traceExpression is trace for _parseExpression, named after the binding power it parses at.
+// It runs for every expression, so the name is only built when tracing is on.
+func (ps *Parser) traceExpression(bindingPower int) string {
+	if ps._tracer == nil {
+		return ""
+	}
+	return ps.trace("_parseExpression(" + precedenceName(bindingPower) + ")")
+}
+
+func (ps *Parser) untrace(name string) {
+	if ps._tracer == nil {
+		return
+	}
+	ps._traceDepth--
+	ps.tracePrint("END " + name)
+}
+
+// Helper function that prints a trace line along with the current and the peek token
+func (ps *Parser) tracePrint(msg string) {
+	fmt.Fprintf(ps._tracer, "%s%s\tcurrent=%s peek=%s peek_bp=%s\n",
+		strings.Repeat("\t", ps._traceDepth), msg,
+		traceToken(ps.currentToken), traceToken(ps.peekToken), precedenceName(ps.peekPrecedence()))
+}
+
+func traceToken(tok token.Token) string {
+	switch tok.Literal {
+	case "":
+		return string(tok.Type)
+	case string(tok.Type):
+		return fmt.Sprintf("%q", tok.Literal)
+	}
+	return fmt.Sprintf("%s%q", tok.Type, tok.Literal)
+}
+
+var precedenceNames = map[int]string{
+	LOWEST:       "LOWEST",
+	EQUALS:       "EQUALS",
+	LESS_GREATER: "LESS_GREATER",
+	PIPE:         "PIPE",
+	SUM:          "SUM",
+	PRODUCT:      "PRODUCT",
+	PREFIX:       "PREFIX",
+	CALL:         "CALL",
+}
+
+func precedenceName(bindingPower int) string {
+	if name, ok := precedenceNames[bindingPower]; ok {
+		return name
+	}
+	return fmt.Sprint(bindingPower)
+}
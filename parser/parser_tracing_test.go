@@ -0,0 +1,94 @@
+package parser
+
+import (
+	"bytes"
+	"monkey/lexer"
+	"strings"
+	"testing"
+)
+
+func TestTrace(t *testing.T) {
+	var out bytes.Buffer
+	ps := New(lexer.New("1 + 2 * 3"))
+	ps.SetTrace(&out)
+	ps.ParseProgram()
+
+	want := []string{
+		`BEGIN parseStatement	current=INT"1" peek="+" peek_bp=SUM`,
+		`	BEGIN parseExpressionStatement	current=INT"1" peek="+" peek_bp=SUM`,
+		`		BEGIN _parseExpression(LOWEST)	current=INT"1" peek="+" peek_bp=SUM`,
+		`			BEGIN parseIntegerLiteral	current=INT"1" peek="+" peek_bp=SUM`,
+		`			END parseIntegerLiteral	current=INT"1" peek="+" peek_bp=SUM`,
+		`			BEGIN parseInfixExpression	current="+" peek=INT"2" peek_bp=LOWEST`,
+		`				BEGIN _parseExpression(SUM)	current=INT"2" peek="*" peek_bp=PRODUCT`,
+		`					BEGIN parseIntegerLiteral	current=INT"2" peek="*" peek_bp=PRODUCT`,
+		`					END parseIntegerLiteral	current=INT"2" peek="*" peek_bp=PRODUCT`,
+		`					BEGIN parseInfixExpression	current="*" peek=INT"3" peek_bp=LOWEST`,
+		`						BEGIN _parseExpression(PRODUCT)	current=INT"3" peek=EOF peek_bp=LOWEST`,
+		`							BEGIN parseIntegerLiteral	current=INT"3" peek=EOF peek_bp=LOWEST`,
+		`							END parseIntegerLiteral	current=INT"3" peek=EOF peek_bp=LOWEST`,
+		`						END _parseExpression(PRODUCT)	current=INT"3" peek=EOF peek_bp=LOWEST`,
+		`					END parseInfixExpression	current=INT"3" peek=EOF peek_bp=LOWEST`,
+		`				END _parseExpression(SUM)	current=INT"3" peek=EOF peek_bp=LOWEST`,
+		`			END parseInfixExpression	current=INT"3" peek=EOF peek_bp=LOWEST`,
+		`		END _parseExpression(LOWEST)	current=INT"3" peek=EOF peek_bp=LOWEST`,
+		`	END parseExpressionStatement	current=INT"3" peek=EOF peek_bp=LOWEST`,
+		`END parseStatement	current=INT"3" peek=EOF peek_bp=LOWEST`,
+	}
+	got := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(got) != len(want) {
+		t.Fatalf("got %d trace lines, want %d:\n%s", len(got), len(want), out.String())
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d:\ngot  %q\nwant %q", i+1, got[i], want[i])
+		}
+	}
+}
+
+func TestTraceBalanced(t *testing.T) {
+	inputs := []string{
+		"let f = fn(a, b = 2, ...rest) { return a + b; };",
+		"match (x) { [a, ...b] if a > 0 => a, _ => 0 }",
+		"xs |> map(x => x * 2) |> sum",
+		"if (a < b) { a } else { b }",
+	}
+	for _, input := range inputs {
+		var out bytes.Buffer
+		ps := New(lexer.New(input))
+		ps.SetTrace(&out)
+		ps.ParseProgram()
+		depth := 0
+		for _, line := range strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n") {
+			indent := len(line) - len(strings.TrimLeft(line, "\t"))
+			switch {
+			case strings.HasPrefix(line[indent:], "BEGIN "):
+				if indent != depth {
+					t.Errorf("%q: %q is indented %d, want %d", input, line, indent, depth)
+				}
+				depth++
+			case strings.HasPrefix(line[indent:], "END "):
+				depth--
+				if indent != depth {
+					t.Errorf("%q: %q is indented %d, want %d", input, line, indent, depth)
+				}
+			default:
+				t.Errorf("%q: unexpected trace line %q", input, line)
+			}
+		}
+		if depth != 0 {
+			t.Errorf("%q: %d parse functions were never left", input, depth)
+		}
+	}
+}
+
+func TestTraceOff(t *testing.T) {
+	var out bytes.Buffer
+	ps := New(lexer.New("1 + 2"))
+	ps.SetTrace(&out)
+	ps.SetTrace(nil)
+	ps.ParseProgram()
+	if out.Len() != 0 {
+		t.Errorf("tracing turned off, but got %q", out.String())
+	}
+}
@@ -0,0 +1,57 @@
+package parser
+
+import (
+	"monkey/ast"
+	"monkey/token"
+	"reflect"
+)
+
+// A NodeSpan is the range of tokens an ast.Node was parsed from: Tokens()[Start : End+1]
+type NodeSpan struct {
+	Node  ast.Node
+	Start int
+	End   int
+}
+
+// RecordSpans makes the parser keep every token it reads and the span of every node it builds.
+// It must be called before parsing; package cst uses it to rebuild the source around the ast.
+func (ps *Parser) RecordSpans() {
+	ps._tokens = []token.Token{ps.currentToken, ps.peekToken}
+	ps._spans = []NodeSpan{}
+	ps._spanOf = map[ast.Node]int{}
+}
+
+// Tokens returns the tokens read so far, when RecordSpans is on
+func (ps *Parser) Tokens() []token.Token {
+	return ps._tokens
+}
+
+// Spans returns the span of every node built so far, when RecordSpans is on.
+// A node is listed after all the nodes it contains.
+func (ps *Parser) Spans() []NodeSpan {
+	spans := []NodeSpan{}
+	for _, span := range ps._spans {
+		if span.Node != nil {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+// Helper function that returns the index of the current token in ps._tokens
+func (ps *Parser) tokenIndex() int {
+	return len(ps._tokens) - 2
+}
+
+// Helper function that records that node spans from the token at start up to the current token.
+// A node recorded again, e.g. a grouped expression once its parentheses are read, replaces the old span.
+func (ps *Parser) recordSpan(node ast.Node, start int) {
+	if ps._tokens == nil || node == nil || reflect.ValueOf(node).IsNil() {
+		return
+	}
+	if i, ok := ps._spanOf[node]; ok {
+		ps._spans[i].Node = nil
+	}
+	ps._spanOf[node] = len(ps._spans)
+	ps._spans = append(ps._spans, NodeSpan{Node: node, Start: start, End: ps.tokenIndex()})
+}
@@ -0,0 +1,246 @@
+// Package resolver binds every use of a name in a program to the declaration it refers to,
+// before the program runs.
+//
+// Scopes are lexical: the program, every block, the parameters of every function and every
+// match arm open one. A name is in effect from the statement after its let, so using it
+// earlier is an error, except from inside a function literal, whose body only runs when it is
+// called: there every declaration of an enclosing scope is visible, which is what lets
+// `let fact = fn(n) { ... fact(n - 1) ... }` call itself.
+package resolver
+
+import (
+	"monkey/ast"
+	"monkey/diag"
+	"monkey/token"
+)
+
+// Resolve fills in Decl and Depth of every ast.Identifier in program. predeclared are names,
+// such as builtins, that are in scope everywhere. Undefined names and names used before their
+// definition are reported as errors, declarations that shadow a variable of an enclosing scope
+// as warnings. The diagnostics are sorted by position.
+func Resolve(program *ast.Program, predeclared ...string) diag.List {
+	universe := newScope(nil, false)
+	for i, name := range predeclared {
+		universe.declare(&ast.Identifier{Token: token.Token{Type: token.IDENTIFIER, Literal: name}, Value: name}, -len(predeclared)+i)
+	}
+	r := &resolver{scope: universe, universe: universe}
+	r.statements(program.Statements)
+	r.diags.Sort()
+	return r.diags
+}
+
+type resolver struct {
+	scope    *scope
+	universe *scope
+	diags    diag.List
+}
+
+type scope struct {
+	parent   *scope
+	function bool // Holds the parameters of a function, whose body only runs when it is called
+	decls    map[string][]declaration
+	next     int // The index of the binding being processed; declarations with a smaller index are in effect
+}
+
+// A declaration is a binding identifier along with the index of the statement, parameter or
+// match arm pattern that makes it within its scope
+type declaration struct {
+	ident *ast.Identifier
+	index int
+}
+
+func newScope(parent *scope, function bool) *scope {
+	return &scope{parent: parent, function: function, decls: map[string][]declaration{}}
+}
+
+func (s *scope) declare(id *ast.Identifier, index int) {
+	s.decls[id.Value] = append(s.decls[id.Value], declaration{id, index})
+}
+
+// Helper function that returns the declaration of name in effect at the scope's current binding,
+// or, when deferred, the one that will be once the scope has run to the end
+func (s *scope) visible(name string, deferred bool) (*ast.Identifier, bool) {
+	list := s.decls[name]
+	for i := len(list) - 1; i >= 0; i-- {
+		if list[i].index < s.next {
+			return list[i].ident, true
+		}
+	}
+	if deferred && len(list) > 0 {
+		return list[0].ident, true
+	}
+	return nil, false
+}
+
+func (r *resolver) push(function bool) *scope {
+	r.scope = newScope(r.scope, function)
+	return r.scope
+}
+
+func (r *resolver) pop() {
+	r.scope = r.scope.parent
+}
+
+/** Scopes **/
+
+// Helper function that resolves a list of statements in a new scope
+func (r *resolver) statements(list []ast.Statement) {
+	s := r.push(false)
+	defer r.pop()
+	// all declarations are known up front, which tells a later definition from none at all
+	for i, stmt := range list {
+		if let, ok := stmt.(*ast.LET_Statement); ok && let.Name != nil {
+			for _, id := range ast.BoundIdentifiers(let.Name) {
+				s.declare(id, i)
+			}
+		}
+	}
+	for i, stmt := range list {
+		s.next = i
+		r.statement(stmt)
+	}
+	s.next = len(list)
+}
+
+func (r *resolver) function(fn *ast.FunctionLiteral) {
+	s := r.push(true)
+	defer r.pop()
+	for i, param := range fn.Parameters {
+		for _, id := range ast.BoundIdentifiers(param.Name) {
+			s.declare(id, i)
+		}
+	}
+	// a default value sees the parameters before it
+	for i, param := range fn.Parameters {
+		s.next = i
+		if param.Default != nil {
+			r.expression(param.Default)
+		}
+		r.bind(param.Name)
+	}
+	s.next = len(fn.Parameters)
+	if fn.Body != nil {
+		r.statements(fn.Body.Statemens)
+	}
+}
+
+func (r *resolver) matchArm(arm *ast.MATCH_Arm) {
+	s := r.push(false)
+	defer r.pop()
+	for _, id := range ast.BoundIdentifiers(arm.Pattern) {
+		s.declare(id, 0)
+	}
+	r.bind(arm.Pattern)
+	s.next = 1
+	if arm.Guard != nil {
+		r.expression(arm.Guard)
+	}
+	r.expression(arm.Body)
+}
+
+// Helper function that annotates the identifiers a pattern binds in the current scope, which
+// already holds their declarations, and warns about those that shadow an enclosing scope's
+func (r *resolver) bind(pattern ast.Pattern) {
+	for _, id := range ast.BoundIdentifiers(pattern) {
+		id.Decl, id.Depth = id, 0
+		if outer, s := r.lookup(r.scope.parent, id.Value, r.scope.function); outer != nil && s != r.universe {
+			r.diags.Warnf(id.Token.Pos, "%s shadows the declaration at %s", id.Value, outer.Token.Pos)
+		}
+	}
+}
+
+// Helper function that finds the declaration of name visible from scope s outwards, along
+// with the scope that holds it. deferred is whether s is already outside the running function.
+func (r *resolver) lookup(s *scope, name string, deferred bool) (*ast.Identifier, *scope) {
+	for ; s != nil; s = s.parent {
+		if id, ok := s.visible(name, deferred); ok {
+			return id, s
+		}
+		deferred = deferred || s.function
+	}
+	return nil, nil
+}
+
+// Helper function that resolves a use of a name
+func (r *resolver) use(id *ast.Identifier) {
+	depth := 0
+	deferred := false
+	var later *ast.Identifier
+	for s := r.scope; s != nil; s = s.parent {
+		if decl, ok := s.visible(id.Value, deferred); ok {
+			id.Decl, id.Depth = decl, depth
+			return
+		}
+		if list := s.decls[id.Value]; later == nil && len(list) > 0 {
+			later = list[0].ident
+		}
+		deferred = deferred || s.function
+		depth++
+	}
+	id.Decl, id.Depth = nil, 0
+	if later != nil {
+		r.diags.Errorf(id.Token.Pos, "%s used before its definition at %s", id.Value, later.Token.Pos)
+		return
+	}
+	r.diags.Errorf(id.Token.Pos, "undefined: %s", id.Value)
+}
+
+/** Statements and Expressions **/
+
+func (r *resolver) statement(stmt ast.Statement) {
+	switch s := stmt.(type) {
+	case *ast.LET_Statement:
+		// the value is evaluated before the names are bound, so `let x = x` refers to an outer x
+		if s.Value != nil {
+			r.expression(s.Value)
+		}
+		if s.Name != nil {
+			r.bind(s.Name)
+		}
+	case *ast.RETURN_Statement:
+		if s.ReturnValue != nil {
+			r.expression(s.ReturnValue)
+		}
+	case *ast.EXPRESSION_Statement:
+		if s.Expression != nil {
+			r.expression(s.Expression)
+		}
+	case *ast.BlockStatement:
+		r.statements(s.Statemens)
+	}
+}
+
+func (r *resolver) expression(expr ast.Expression) {
+	switch e := expr.(type) {
+	case *ast.Identifier:
+		r.use(e)
+	case *ast.PREFIX_Expression:
+		r.expression(e.Right)
+	case *ast.INFIX_Expression:
+		r.expression(e.Left)
+		r.expression(e.Right)
+	case *ast.IF_Expression:
+		r.expression(e.Condition)
+		if e.Consequence != nil {
+			r.statements(e.Consequence.Statemens)
+		}
+		if e.Alternative != nil {
+			r.statements(e.Alternative.Statemens)
+		}
+	case *ast.FunctionLiteral:
+		r.function(e)
+	case *ast.CALL_Expression:
+		r.expression(e.Function)
+		for _, arg := range e.Arguments {
+			r.expression(arg)
+		}
+	case *ast.KEYWORD_Argument:
+		// the name is a parameter of the callee, not a variable
+		r.expression(e.Value)
+	case *ast.MATCH_Expression:
+		r.expression(e.Subject)
+		for _, arm := range e.Arms {
+			r.matchArm(arm)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package resolver
+
+import (
+	"monkey/ast"
+	"monkey/lexer"
+	"monkey/parser"
+	"reflect"
+	"testing"
+)
+
+// Helper function that parses input and fails the test on a parse error
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+	ps := parser.New(lexer.New(input))
+	program := ps.ParseProgram()
+	if errs := ps.Errors(); len(errs) > 0 {
+		t.Fatalf("parsing %q: %s", input, errs)
+	}
+	return program
+}
+
+func TestDiagnostics(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"let x = 1; x", nil},
+		{"y", []string{"1:1: error: undefined: y"}},
+		{"let x = y + z;", []string{"1:9: error: undefined: y", "1:13: error: undefined: z"}},
+		{"x; let x = 1;", []string{"1:1: error: x used before its definition at 1:8"}},
+		{"let x = x;", []string{"1:9: error: x used before its definition at 1:5"}},
+		{"if (true) { let a = 1; }; a", []string{"1:27: error: undefined: a"}},
+		{"fn(a) { a }; a", []string{"1:14: error: undefined: a"}},
+		{"fn(a = b, b = 1) { a }", []string{"1:8: error: b used before its definition at 1:11"}},
+		{"fn(a, b = a) { b }", nil},
+		{"match (1) { n if n => n, _ => n }", []string{"1:31: error: undefined: n"}},
+		{"f(k: 1)", []string{"1:1: error: undefined: f"}},
+		{"let [a, ...b] = c;", []string{"1:17: error: undefined: c"}},
+		// shadowing
+		{"let x = 1; fn(x) { x }", []string{"1:15: warning: x shadows the declaration at 1:5"}},
+		{"let x = 1; if (x) { let x = 2; x }", []string{"1:25: warning: x shadows the declaration at 1:5"}},
+		{"let x = 1; match (x) { [x] => x }", []string{"1:25: warning: x shadows the declaration at 1:5"}},
+		{"let x = 1; let x = 2; x", nil},
+		{"let len = 1;", nil},
+	}
+	for _, tt := range tests {
+		diags := Resolve(parse(t, tt.input), "len")
+		var got []string
+		for _, d := range diags {
+			got = append(got, d.String())
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestDeferredVisibility(t *testing.T) {
+	// a function body runs only when called, so it sees every declaration of the scopes around it
+	tests := []string{
+		"let fact = fn(n) { if (n < 1) { 1 } else { n * fact(n - 1) } };",
+		"let even = fn(n) { odd(n) }; let odd = fn(n) { even(n) };",
+		"let f = fn() { fn() { g } }; let g = 1;",
+	}
+	for _, input := range tests {
+		if diags := Resolve(parse(t, input)); len(diags) > 0 {
+			t.Errorf("%q: unexpected diagnostics %s", input, diags[0])
+		}
+	}
+	// but only the function's own scope defers, not the blocks within it
+	diags := Resolve(parse(t, "fn() { x; let x = 1; }"))
+	if len(diags) != 1 || diags[0].Msg != "x used before its definition at 1:15" {
+		t.Errorf("got %v, want x used before its definition", diags)
+	}
+}
+
+func TestDeclAndDepth(t *testing.T) {
+	program := parse(t, "let a = 1; let f = fn(b) { if (b) { a + b } }; f(k: a)")
+	if diags := Resolve(program); len(diags) > 0 {
+		t.Fatalf("unexpected diagnostics %s", diags[0])
+	}
+	uses := map[string][]*ast.Identifier{}
+	ast.Inspect(program, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Identifier); ok {
+			uses[id.Value] = append(uses[id.Value], id)
+		}
+		return true
+	})
+
+	declA := program.Statements[0].(*ast.LET_Statement).Name.(*ast.Identifier)
+	declF := program.Statements[1].(*ast.LET_Statement).Name.(*ast.Identifier)
+	declB := uses["b"][0]
+	tests := []struct {
+		id    *ast.Identifier
+		decl  *ast.Identifier
+		depth int
+	}{
+		{declA, declA, 0},
+		{declB, declB, 0},
+		// a inside the if block: block, function body, parameters, then the program
+		{uses["a"][1], declA, 3},
+		// b as the condition is in the function body, inside the if block it is one scope further
+		{uses["b"][1], declB, 1},
+		{uses["b"][2], declB, 2},
+		{uses["f"][1], declF, 0},
+		{uses["a"][2], declA, 0},
+		// the keyword argument name is not a variable
+		{uses["k"][0], nil, 0},
+	}
+	for i, tt := range tests {
+		if tt.id.Decl != tt.decl || tt.id.Depth != tt.depth {
+			t.Errorf("test %d: %s has Decl %p, Depth %d, want %p, %d", i, tt.id.Value, tt.id.Decl, tt.id.Depth, tt.decl, tt.depth)
+		}
+	}
+}
+
+func TestPredeclared(t *testing.T) {
+	program := parse(t, "len(x)")
+	diags := Resolve(program, "len", "x")
+	if len(diags) > 0 {
+		t.Fatalf("unexpected diagnostics %s", diags[0])
+	}
+	call := program.Statements[0].(*ast.EXPRESSION_Statement).Expression.(*ast.CALL_Expression)
+	if id := call.Function.(*ast.Identifier); id.Decl == nil || id.Decl.Value != "len" || id.Depth != 1 {
+		t.Errorf("len resolved to %v at depth %d", id.Decl, id.Depth)
+	}
+}
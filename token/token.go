@@ -9,6 +9,18 @@ type TokenType string
 type Token struct {
 	Type    TokenType // The type of the token
 	Literal string    // The literal value of the token
+	Pos     Position  // Where the token starts in the source
+}
+
+// A Position in the source; Line and Column are 1-based, Offset is the 0-based byte offset
+type Position struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
 }
 
 // The supported types of tokens
@@ -25,6 +37,8 @@ const (
 	RPAREN     = ")"
 	LBRACE     = "{"
 	RBRACE     = "}"
+	LBRACKET   = "["
+	RBRACKET   = "]"
 	MINUS      = "-"
 	BANG       = "!"
 	ASTERISK   = "*"
@@ -33,6 +47,10 @@ const (
 	GT         = ">"
 	EQ         = "=="
 	NOT_EQ     = "!="
+	COLON      = ":"
+	ELLIPSIS   = "..."
+	ARROW      = "=>"
+	PIPE       = "|>"
 	/** Keywords **/
 	FUNCTION = "FUNCTION"
 	LET      = "LET"
@@ -41,6 +59,8 @@ const (
 	IF       = "IF"
 	ELSE     = "ELSE"
 	RETURN   = "RETURN"
+	MATCH    = "MATCH"
+	MACRO    = "MACRO"
 )
 
 func (t Token) Print() {
@@ -0,0 +1,428 @@
+// Package typecheck infers the types of a Monkey program and reports the operations that would
+// fail at run time because a value has the wrong type.
+//
+// Annotations are optional: `let x: int = 5` and `fn(a: int, b: string): bool { ... }` state a
+// type, everything else is inferred by unification. The types are int, bool, string, arrays
+// `[int]`, hashes `{string: int}` and functions `fn(int, bool): int`. A value used as a
+// condition may have any type, and an if without else has the type of its consequence.
+//
+// Names bound by let are generic in the types their value leaves open, as in Hindley-Milner
+// inference: after `let id = fn(x) { x }`, both `id(1)` and `id(true)` check.
+package typecheck
+
+import (
+	"fmt"
+	"monkey/ast"
+	"monkey/diag"
+	"monkey/token"
+)
+
+// Info holds the types found by Check. They may contain unknown types; use Resolve to see what they were bound to.
+type Info struct {
+	Types map[ast.Expression]Type  // The type of every expression
+	Defs  map[*ast.Identifier]Type // The type of every name bound by a let, a parameter or a match arm
+}
+
+// Check infers the types in program and reports type errors, sorted by position.
+// Names that are not defined are given an unknown type; package resolver reports them.
+func Check(program *ast.Program) (*Info, diag.List) {
+	c := &checker{info: &Info{Types: map[ast.Expression]Type{}, Defs: map[*ast.Identifier]Type{}}}
+	c.statements(program.Statements)
+	c.diags.Sort()
+	return c.info, c.diags
+}
+
+type checker struct {
+	info    *Info
+	diags   diag.List
+	scope   *scope
+	results []Type // The result types of the enclosing functions, innermost last
+	vars    int
+	level   int // The number of lets whose value is being checked
+}
+
+type scope struct {
+	parent  *scope
+	names   map[string]Type
+	defined map[string]bool // The names whose let has been checked
+	forward map[string]bool // The names used before their let, from inside a function
+}
+
+func (c *checker) push() {
+	c.scope = &scope{parent: c.scope, names: map[string]Type{}, defined: map[string]bool{}, forward: map[string]bool{}}
+}
+
+func (c *checker) pop() {
+	c.scope = c.scope.parent
+}
+
+// Helper function that finds the type of a use of name, with its own copy of a generic type
+func (c *checker) lookup(name string) (Type, bool) {
+	for s := c.scope; s != nil; s = s.parent {
+		if t, ok := s.names[name]; ok {
+			if !s.defined[name] {
+				s.forward[name] = true
+			}
+			return c.instantiate(t), true
+		}
+	}
+	return nil, false
+}
+
+func (c *checker) fresh() *Var {
+	c.vars++
+	return &Var{id: c.vars, level: c.level}
+}
+
+// Helper function that unifies got with want, and otherwise reports "<what> has type <got>, expected <want>"
+func (c *checker) expect(pos token.Position, got, want Type, what string, args ...interface{}) bool {
+	if unify(got, want) {
+		return true
+	}
+	types := TypeStrings(got, want)
+	c.diags.Errorf(pos, "%s has type %s, expected %s", fmt.Sprintf(what, args...), types[0], types[1])
+	return false
+}
+
+/** Statements **/
+
+// Helper function that checks a list of statements in a new scope and returns the type of its
+// value, which is that of the last statement
+func (c *checker) statements(list []ast.Statement) Type {
+	c.push()
+	defer c.pop()
+	// names can be used before their let from inside functions, so they are all known up front
+	for _, stmt := range list {
+		if let, ok := stmt.(*ast.LET_Statement); ok && let.Name != nil {
+			for _, id := range ast.BoundIdentifiers(let.Name) {
+				c.scope.names[id.Value] = c.fresh()
+			}
+		}
+	}
+	var result Type = c.fresh()
+	for _, stmt := range list {
+		result = c.statement(stmt)
+	}
+	return result
+}
+
+func (c *checker) statement(stmt ast.Statement) Type {
+	switch s := stmt.(type) {
+	case *ast.LET_Statement:
+		c.let(s)
+	case *ast.RETURN_Statement:
+		t := c.expression(s.ReturnValue)
+		if len(c.results) > 0 {
+			c.expect(ast.Pos(s.ReturnValue), t, c.results[len(c.results)-1], "return value")
+		}
+		return t
+	case *ast.EXPRESSION_Statement:
+		return c.expression(s.Expression)
+	case *ast.BlockStatement:
+		return c.statements(s.Statemens)
+	}
+	return c.fresh()
+}
+
+// A let makes its names generic, unless they were used before it: those uses share one type
+// with the let, as they would in a function that took the name as a parameter.
+func (c *checker) let(s *ast.LET_Statement) {
+	generic := []*ast.Identifier{}
+	for _, id := range ast.BoundIdentifiers(s.Name) {
+		if c.scope.defined[id.Value] || !c.scope.forward[id.Value] {
+			generic = append(generic, id)
+		}
+	}
+	c.level++
+	// the value can still call itself, with the type it has while it is checked
+	for _, id := range generic {
+		c.scope.names[id.Value] = c.fresh()
+	}
+	t := c.expression(s.Value)
+	if s.Type != nil {
+		want := c.typeExpr(s.Type)
+		if c.expect(ast.Pos(s.Value), t, want, "value of %s", s.Name.Node_String()) {
+			t = want
+		}
+	}
+	c.level--
+	c.bind(s.Name, t, false)
+	for _, id := range generic {
+		c.scope.names[id.Value] = c.generalize(c.scope.names[id.Value])
+	}
+}
+
+/** Patterns **/
+
+// Helper function that gives the names a pattern binds their types, for a value of type t.
+// A let binds names that were declared with an unknown type, which their uses in the value or
+// before the let may have constrained; parameters and match arms bind fresh names.
+func (c *checker) bind(pattern ast.Pattern, t Type, declare bool) {
+	switch p := pattern.(type) {
+	case *ast.Identifier:
+		if declared, ok := c.scope.names[p.Value]; ok && !declare {
+			c.expect(p.Token.Pos, t, declared, "%s", p.Value)
+		}
+		c.scope.names[p.Value] = t
+		c.scope.defined[p.Value] = true
+		c.info.Defs[p] = t
+	case *ast.ARRAY_Pattern:
+		element := c.fresh()
+		c.expect(p.Token.Pos, t, &Array{element}, "value destructured by %s", p.Node_String())
+		for _, el := range p.Elements {
+			c.bind(el, element, declare)
+		}
+		if p.Rest != nil {
+			c.bind(p.Rest, &Array{element}, declare)
+		}
+	case *ast.HASH_Pattern:
+		value := c.fresh()
+		c.expect(p.Token.Pos, t, &Hash{String, value}, "value destructured by %s", p.Node_String())
+		for _, en := range p.Entries {
+			c.bind(en.Value, value, declare)
+		}
+	case *ast.LITERAL_Pattern:
+		c.expect(ast.Pos(p), c.expression(p.Value), t, "pattern %s", p.Node_String())
+	}
+}
+
+/** Expressions **/
+
+func (c *checker) expression(expr ast.Expression) Type {
+	if expr == nil {
+		return c.fresh()
+	}
+	t := c.expressionType(expr)
+	c.info.Types[expr] = t
+	return t
+}
+
+func (c *checker) expressionType(expr ast.Expression) Type {
+	switch e := expr.(type) {
+	case *ast.INTEGER_Literal:
+		return Int
+	case *ast.Boolean:
+		return Bool
+	case *ast.Identifier:
+		if t, ok := c.lookup(e.Value); ok {
+			return t
+		}
+		return c.fresh()
+	case *ast.PREFIX_Expression:
+		t := c.expression(e.Right)
+		if e.Token.Type == token.MINUS {
+			c.expect(ast.Pos(e.Right), t, Int, "operand of -")
+			return Int
+		}
+		// ! works on any value, by its truthiness
+		return Bool
+	case *ast.INFIX_Expression:
+		return c.infix(e)
+	case *ast.IF_Expression:
+		c.expression(e.Condition)
+		t := c.statements(e.Consequence.Statemens)
+		if e.Alternative != nil {
+			c.expect(e.Alternative.Token.Pos, c.statements(e.Alternative.Statemens), t, "else branch")
+		}
+		return t
+	case *ast.FunctionLiteral:
+		return c.function(e)
+	case *ast.CALL_Expression:
+		return c.call(e)
+	case *ast.KEYWORD_Argument:
+		return c.expression(e.Value)
+	case *ast.MATCH_Expression:
+		subject := c.expression(e.Subject)
+		var result Type = c.fresh()
+		for i, arm := range e.Arms {
+			c.push()
+			c.bind(arm.Pattern, subject, true)
+			if arm.Guard != nil {
+				c.expression(arm.Guard)
+			}
+			c.expect(ast.Pos(arm.Body), c.expression(arm.Body), result, "arm %d", i+1)
+			c.pop()
+		}
+		return result
+	}
+	return c.fresh()
+}
+
+func (c *checker) infix(e *ast.INFIX_Expression) Type {
+	left, right := c.expression(e.Left), c.expression(e.Right)
+	switch e.Token.Type {
+	case token.EQ, token.NOT_EQ:
+		c.expect(ast.Pos(e.Right), right, left, "right operand of %s", e.Token.Literal)
+		return Bool
+	case token.LT, token.GT:
+		c.expect(ast.Pos(e.Left), left, Int, "left operand of %s", e.Token.Literal)
+		c.expect(ast.Pos(e.Right), right, Int, "right operand of %s", e.Token.Literal)
+		return Bool
+	}
+	c.expect(ast.Pos(e.Left), left, Int, "left operand of %s", e.Token.Literal)
+	c.expect(ast.Pos(e.Right), right, Int, "right operand of %s", e.Token.Literal)
+	return Int
+}
+
+func (c *checker) function(fn *ast.FunctionLiteral) Type {
+	ft := &Function{Literal: fn}
+	c.push()
+	defer c.pop()
+	for _, param := range fn.Parameters {
+		var t Type
+		switch {
+		case param.Type != nil:
+			t = c.typeExpr(param.Type)
+			if _, ok := prune(t).(*Array); param.Variadic && !ok {
+				c.diags.Errorf(ast.Pos(param.Type), "rest parameter %s must have an array type, not %s", param.Name.Node_String(), TypeString(t))
+				t = &Array{c.fresh()}
+			}
+		case param.Variadic:
+			t = &Array{c.fresh()}
+		default:
+			t = c.fresh()
+		}
+		if param.Default != nil {
+			c.expect(ast.Pos(param.Default), c.expression(param.Default), t, "default value of %s", param.Name.Node_String())
+		}
+		c.bind(param.Name, t, true)
+		ft.Params = append(ft.Params, t)
+		ft.Variadic = param.Variadic
+	}
+
+	if fn.ReturnType != nil {
+		ft.Result = c.typeExpr(fn.ReturnType)
+	} else {
+		ft.Result = c.fresh()
+	}
+	c.results = append(c.results, ft.Result)
+	body := c.statements(fn.Body.Statemens)
+	c.results = c.results[:len(c.results)-1]
+	// a body that ends in a return has been checked by the return
+	if n := len(fn.Body.Statemens); n > 0 {
+		if _, ok := fn.Body.Statemens[n-1].(*ast.RETURN_Statement); !ok {
+			c.expect(ast.Pos(fn.Body.Statemens[n-1]), body, ft.Result, "result of the function")
+		}
+	}
+	return ft
+}
+
+func (c *checker) call(call *ast.CALL_Expression) Type {
+	callee := c.expression(call.Function)
+	args := make([]Type, len(call.Arguments))
+	for i, arg := range call.Arguments {
+		args[i] = c.expression(arg)
+	}
+	name := call.Function.Node_String()
+
+	switch f := prune(callee).(type) {
+	case *Var:
+		// all that is known is that it is a function taking these arguments
+		ft := &Function{Params: args, Result: c.fresh()}
+		for _, arg := range call.Arguments {
+			if kw, ok := arg.(*ast.KEYWORD_Argument); ok {
+				c.diags.Errorf(kw.Token.Pos, "keyword argument %s to %s, whose parameters are not known", kw.Name.Value, name)
+				return ft.Result
+			}
+		}
+		if !unify(f, ft) {
+			// e.g. x(x), which would need a type that contains itself
+			types := TypeStrings(f, ft)
+			c.diags.Errorf(ast.Pos(call.Function), "cannot call %s of type %s as %s", name, types[0], types[1])
+		}
+		return ft.Result
+	case *Function:
+		if f.Literal != nil {
+			c.callLiteral(call, f, args, name)
+		} else {
+			c.callType(call, f, args, name)
+		}
+		return f.Result
+	}
+	c.diags.Errorf(ast.Pos(call.Function), "cannot call %s of type %s", name, TypeString(callee))
+	return c.fresh()
+}
+
+// Helper function that checks a call to a function whose literal is known, which may use default
+// values and keyword arguments
+func (c *checker) callLiteral(call *ast.CALL_Expression, f *Function, args []Type, name string) {
+	bindings, err := f.Literal.BindArguments(call.Arguments)
+	if err != nil {
+		c.diags.Errorf(ast.Pos(call), "%v in call to %s", err, name)
+		return
+	}
+	// the bindings hold the value of a keyword argument rather than the argument
+	index := map[ast.Expression]int{}
+	for i, arg := range call.Arguments {
+		index[arg] = i
+		if kw, ok := arg.(*ast.KEYWORD_Argument); ok {
+			index[kw.Value] = i
+		}
+	}
+	for i, b := range bindings {
+		want := f.Params[i]
+		if b.Parameter.Variadic {
+			want = c.restElement(ast.Pos(call), want, name)
+		}
+		for _, value := range b.Values {
+			arg := index[value]
+			c.expect(ast.Pos(value), args[arg], want, "argument %d to %s", arg+1, name)
+		}
+	}
+}
+
+// Helper function that checks a call to a function of which only the type is known
+func (c *checker) callType(call *ast.CALL_Expression, f *Function, args []Type, name string) {
+	fixed := len(f.Params)
+	if f.Variadic {
+		fixed--
+	}
+	if len(args) < fixed || len(args) > fixed && !f.Variadic {
+		c.diags.Errorf(ast.Pos(call), "wrong number of arguments in call to %s: want %d, got %d", name, fixed, len(args))
+		return
+	}
+	for i, arg := range call.Arguments {
+		if kw, ok := arg.(*ast.KEYWORD_Argument); ok {
+			c.diags.Errorf(kw.Token.Pos, "keyword argument %s to %s, whose parameters are not known", kw.Name.Value, name)
+			continue
+		}
+		want := f.Params[len(f.Params)-1]
+		if i < fixed {
+			want = f.Params[i]
+		} else {
+			want = c.restElement(ast.Pos(call), want, name)
+		}
+		c.expect(ast.Pos(arg), args[i], want, "argument %d to %s", i+1, name)
+	}
+}
+
+// Helper function that returns the type of the arguments that the rest parameter of name collects
+func (c *checker) restElement(pos token.Position, rest Type, name string) Type {
+	element := c.fresh()
+	c.expect(pos, rest, &Array{element}, "rest parameter of %s", name)
+	return element
+}
+
+/** Annotations **/
+
+func (c *checker) typeExpr(typ ast.TypeExpr) Type {
+	switch t := typ.(type) {
+	case *ast.TYPE_Name:
+		if basic, ok := basics[t.Value]; ok {
+			return basic
+		}
+		c.diags.Errorf(t.Token.Pos, "unknown type %s", t.Value)
+		return c.fresh()
+	case *ast.ARRAY_Type:
+		return &Array{c.typeExpr(t.Element)}
+	case *ast.HASH_Type:
+		return &Hash{c.typeExpr(t.Key), c.typeExpr(t.Value)}
+	case *ast.FUNCTION_Type:
+		ft := &Function{Result: c.typeExpr(t.Return)}
+		for _, p := range t.Parameters {
+			ft.Params = append(ft.Params, c.typeExpr(p))
+		}
+		return ft
+	}
+	return c.fresh()
+}
@@ -0,0 +1,122 @@
+package typecheck
+
+import (
+	"monkey/ast"
+	"monkey/diag"
+	"monkey/lexer"
+	"monkey/parser"
+	"strings"
+	"testing"
+)
+
+// Helper function that checks src and returns the types of its top-level bindings by name
+func check(t *testing.T, src string) (map[string]string, diag.List) {
+	t.Helper()
+	ps := parser.New(lexer.New(src))
+	program := ps.ParseProgram()
+	if len(ps.Errors()) > 0 {
+		t.Fatalf("parsing %q: %v", src, ps.Errors())
+	}
+	info, diags := Check(program)
+	types := map[string]string{}
+	for _, stmt := range program.Statements {
+		if let, ok := stmt.(*ast.LET_Statement); ok {
+			for _, id := range ast.BoundIdentifiers(let.Name) {
+				types[id.Value] = TypeString(info.Defs[id])
+			}
+		}
+	}
+	return types, diags
+}
+
+func TestInferredTypes(t *testing.T) {
+	tests := []struct {
+		src  string
+		name string
+		want string
+	}{
+		{"let x: int = 5;", "x", "int"},
+		{"let x = 1 < 2;", "x", "bool"},
+		{"let s: string = s;", "s", "string"},
+		{"let xs: [int] = xs;", "xs", "[int]"},
+		{"let ages: {string: int} = ages;", "ages", "{string: int}"},
+		{"let f: fn(int, bool): [int] = f;", "f", "(int, bool) -> [int]"},
+		{"let f: fn(fn(int): int): int = f;", "f", "(int -> int) -> int"},
+		{"let inc = fn(n) { n + 1 };", "inc", "int -> int"},
+		{"let not = fn(b: bool): bool { !b };", "not", "bool -> bool"},
+		{"let pick = fn(c, a: int, b) { if (c) { a } else { b } };", "pick", "(a, int, int) -> int"},
+		{"let sum = fn(...xs: [int]) { 0 };", "sum", "(...[int]) -> int"},
+		{"let f = fn(a, b = a * 2) { b };", "f", "(int, int) -> int"},
+		{"let fact = fn(n) { if (n < 2) { 1 } else { n * fact(n - 1) } };", "fact", "int -> int"},
+		{"let xs: [int] = xs; let [head, ...tail] = xs;", "tail", "[int]"},
+		{"let p: {string: bool} = p; let {ok} = p;", "ok", "bool"},
+		{"let r = match (1) { 0 => true, n if n > 1 => false, _ => true };", "r", "bool"},
+		{"let f = fn(x) { return x * 2; };", "f", "int -> int"},
+		// unannotated functions are generalized at their let
+		{"let id = fn(x) { x };", "id", "a -> a"},
+		{"let twice = fn(f, x) { f(f(x)) };", "twice", "(a -> a, a) -> a"},
+		{"let compose = fn(f, g) { fn(x) { f(g(x)) } };", "compose", "(a -> b, c -> a) -> c -> b"},
+		{"let id = fn(x) { x }; let a = id(1); let b = id(true);", "a", "int"},
+		{"let id = fn(x) { x }; let a = id(1); let b = id(true);", "b", "bool"},
+		{"let id = fn(x) { x }; let g = id(id);", "g", "a -> a"},
+		{"let const = fn(x) { fn(y) { x } }; let k = const(1);", "k", "a -> int"},
+		{"let apply = fn(f, ...xs) { f(xs) };", "apply", "([a] -> b, ...[a]) -> b"},
+		{"let first = fn([a, ...r]) { a };", "first", "[a] -> a"},
+		{"let even = fn(n) { if (n == 0) { true } else { odd(n - 1) } }; let odd = fn(n) { if (n == 0) { false } else { even(n - 1) } };", "odd", "int -> bool"},
+	}
+	for _, tt := range tests {
+		types, diags := check(t, tt.src)
+		if len(diags) > 0 {
+			t.Errorf("%q: unexpected diagnostics %v", tt.src, diags)
+		}
+		if got := types[tt.name]; got != tt.want {
+			t.Errorf("%q: %s has type %s, want %s", tt.src, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTypeErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"1 + true;", "1:5: error: right operand of + has type bool, expected int"},
+		{"let f = fn(x) { x(x) };", "1:17: error: cannot call x of type a as a -> b"},
+		{"let id = fn(x) { x }; id(1) + id(true);", "1:31: error: right operand of + has type bool, expected int"},
+		// a parameter is not generalized inside its function
+		{"let f = fn(g) { g(1) + g(true) };", "1:26: error: argument 1 to g has type bool, expected int"},
+		{"let f = fn(x) { x + 1 }; f(f);", "1:28: error: argument 1 to f has type int -> int, expected int"},
+		{"-true;", "1:2: error: operand of - has type bool, expected int"},
+		{"true < 1;", "1:1: error: left operand of < has type bool, expected int"},
+		{"1 == false;", "1:6: error: right operand of == has type bool, expected int"},
+		{"let n = 5; n(1);", "1:12: error: cannot call n of type int"},
+		{"let s: bool = 1;", "1:15: error: value of s has type int, expected bool"},
+		{"let x: float = 1;", "1:8: error: unknown type float"},
+		{"let f = fn(a: int) { a }; f(true);", "1:29: error: argument 1 to f has type bool, expected int"},
+		{"let f = fn(a: int) { a }; f(a: true);", "1:32: error: argument 1 to f has type bool, expected int"},
+		{"let f = fn(a) { a }; f(1, 2);", "1:22: error: wrong number of arguments: want 1, got 2 in call to f"},
+		{"let f: fn(int): int = f; f(1, 2);", "1:26: error: wrong number of arguments in call to f: want 1, got 2"},
+		{"let f: fn(int): int = f; f(a: 1);", "1:28: error: keyword argument a to f, whose parameters are not known"},
+		{"let f = fn(): bool { 1 };", "1:22: error: result of the function has type int, expected bool"},
+		{"let f = fn(): int { return true; };", "1:28: error: return value has type bool, expected int"},
+		{"let f = fn(...xs: int) { xs };", "1:19: error: rest parameter xs must have an array type, not int"},
+		{"let sum = fn(...xs: [int]) { 0 }; sum(1, true);", "1:42: error: argument 2 to sum has type bool, expected int"},
+		{"let f = fn(a: int = true) { a };", "1:21: error: default value of a has type bool, expected int"},
+		{"if (true) { 1 } else { false };", "1:22: error: else branch has type bool, expected int"},
+		{"match (1) { 0 => 1, _ => true };", "1:26: error: arm 2 has type bool, expected int"},
+		{"match (1) { true => 1 };", "1:13: error: pattern true has type bool, expected int"},
+		{"let [a] = 1;", "1:5: error: value destructured by [a] has type int, expected [a]"},
+		{"let f = fn(x) { x + 1 }; let y: bool = f(1);", "1:40: error: value of y has type int, expected bool"},
+		{"let g = fn() { h(1) }; let h = fn(b: bool) { b };", "1:28: error: h has type bool -> bool, expected int -> a"},
+	}
+	for _, tt := range tests {
+		_, diags := check(t, tt.src)
+		got := []string{}
+		for _, d := range diags {
+			got = append(got, d.String())
+		}
+		if len(got) != 1 || got[0] != tt.want {
+			t.Errorf("%q: got diagnostics [%s], want [%s]", tt.src, strings.Join(got, "; "), tt.want)
+		}
+	}
+}
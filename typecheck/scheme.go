@@ -0,0 +1,76 @@
+package typecheck
+
+// A scheme is the type of a name bound by let, in which the unknown types that nothing outside
+// the let constrains may be anything: `let id = fn(x) { x }` has type a -> a for every a.
+// Each use of the name gets its own copy of the type, with fresh variables for those in vars.
+type scheme struct {
+	vars []*Var
+	body Type
+}
+
+// Helper function that makes the type of a let's value generic in the variables made while the
+// value was checked, at a deeper level than the let, that are still unbound
+func (c *checker) generalize(t Type) Type {
+	vars := []*Var{}
+	seen := map[*Var]bool{}
+	var collect func(t Type)
+	collect = func(t Type) {
+		switch t := prune(t).(type) {
+		case *Var:
+			if t.level > c.level && !seen[t] {
+				seen[t] = true
+				vars = append(vars, t)
+			}
+		case *Array:
+			collect(t.Element)
+		case *Hash:
+			collect(t.Key)
+			collect(t.Value)
+		case *Function:
+			for _, p := range t.Params {
+				collect(p)
+			}
+			collect(t.Result)
+		}
+	}
+	collect(t)
+	if len(vars) == 0 {
+		return t
+	}
+	return &scheme{vars: vars, body: t}
+}
+
+// Helper function that gives a use of a generic name its own copy of the type
+func (c *checker) instantiate(t Type) Type {
+	s, ok := t.(*scheme)
+	if !ok {
+		return t
+	}
+	fresh := map[*Var]Type{}
+	for _, v := range s.vars {
+		fresh[v] = c.fresh()
+	}
+	var copy func(t Type) Type
+	copy = func(t Type) Type {
+		switch t := prune(t).(type) {
+		case *Var:
+			if f, ok := fresh[t]; ok {
+				return f
+			}
+			return t
+		case *Array:
+			return &Array{copy(t.Element)}
+		case *Hash:
+			return &Hash{copy(t.Key), copy(t.Value)}
+		case *Function:
+			params := make([]Type, len(t.Params))
+			for i, p := range t.Params {
+				params[i] = copy(p)
+			}
+			return &Function{Params: params, Result: copy(t.Result), Variadic: t.Variadic, Literal: t.Literal}
+		default:
+			return t
+		}
+	}
+	return copy(s.body)
+}
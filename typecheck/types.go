@@ -0,0 +1,160 @@
+package typecheck
+
+import (
+	"monkey/ast"
+	"strings"
+)
+
+// A Type is the static type of a value
+type Type interface {
+	typ()
+}
+
+// A Basic type is int, bool or string
+type Basic struct {
+	Name string
+}
+
+var (
+	Int    = &Basic{"int"}
+	Bool   = &Basic{"bool"}
+	String = &Basic{"string"}
+)
+
+// The basic types by the name used in annotations
+var basics = map[string]*Basic{"int": Int, "bool": Bool, "string": String}
+
+type Array struct {
+	Element Type
+}
+
+type Hash struct {
+	Key   Type
+	Value Type
+}
+
+type Function struct {
+	Params   []Type
+	Result   Type
+	Variadic bool // The last of Params is an Array that collects the remaining arguments
+
+	// The literal the type was inferred from, which lets calls use default values and keyword
+	// arguments; nil for the type of an annotation
+	Literal *ast.FunctionLiteral
+}
+
+// A Var is a type that is not known yet. Unification binds it to the type it stands for.
+type Var struct {
+	id       int
+	instance Type // nil while unbound
+	level    int  // The number of lets whose value was being checked when the Var was made
+}
+
+func (*Basic) typ()    {}
+func (*Array) typ()    {}
+func (*Hash) typ()     {}
+func (*Function) typ() {}
+func (*Var) typ()      {}
+func (*scheme) typ()   {}
+
+// Helper function that follows bound variables to the type they stand for
+func prune(t Type) Type {
+	for {
+		v, ok := t.(*Var)
+		if !ok || v.instance == nil {
+			return t
+		}
+		t = v.instance
+	}
+}
+
+// Resolve returns t with every bound variable replaced by the type it stands for
+func Resolve(t Type) Type {
+	switch t := prune(t).(type) {
+	case *Array:
+		return &Array{Resolve(t.Element)}
+	case *Hash:
+		return &Hash{Resolve(t.Key), Resolve(t.Value)}
+	case *Function:
+		params := make([]Type, len(t.Params))
+		for i, p := range t.Params {
+			params[i] = Resolve(p)
+		}
+		return &Function{Params: params, Result: Resolve(t.Result), Variadic: t.Variadic, Literal: t.Literal}
+	default:
+		return t
+	}
+}
+
+/** Printing **/
+
+// TypeString prints a type: `int`, `[int]`, `{string: int}`, `(int, bool) -> int`, `int -> int`.
+// Unknown types are named a, b, c, ... in order of appearance.
+func TypeString(t Type) string {
+	return TypeStrings(t)[0]
+}
+
+// TypeStrings prints several types with the same names for the same unknown types, as needed
+// when they appear in one message
+func TypeStrings(types ...Type) []string {
+	p := &typePrinter{names: map[*Var]string{}}
+	result := make([]string, len(types))
+	for i, t := range types {
+		result[i] = p.print(t, false)
+	}
+	return result
+}
+
+type typePrinter struct {
+	names map[*Var]string
+}
+
+// operand is whether the type is the lone parameter of a function type, where a function type
+// needs parentheses: `(int -> int) -> int`. Arrows associate to the right, so results need none.
+func (p *typePrinter) print(t Type, operand bool) string {
+	switch t := prune(t).(type) {
+	case *Basic:
+		return t.Name
+	case *Array:
+		return "[" + p.print(t.Element, false) + "]"
+	case *Hash:
+		return "{" + p.print(t.Key, false) + ": " + p.print(t.Value, false) + "}"
+	case *Function:
+		lone := len(t.Params) == 1 && !t.Variadic
+		params := []string{}
+		for i, param := range t.Params {
+			text := p.print(param, lone)
+			if t.Variadic && i == len(t.Params)-1 {
+				text = "..." + text
+			}
+			params = append(params, text)
+		}
+		text := "(" + strings.Join(params, ", ") + ") -> " + p.print(t.Result, false)
+		if lone {
+			text = params[0] + " -> " + p.print(t.Result, false)
+		}
+		if operand {
+			return "(" + text + ")"
+		}
+		return text
+	case *scheme:
+		return p.print(t.body, operand)
+	case *Var:
+		if name, ok := p.names[t]; ok {
+			return name
+		}
+		name := varName(len(p.names))
+		p.names[t] = name
+		return name
+	}
+	return "?"
+}
+
+// Helper function that names the n-th unknown type: a, b, ..., z, a', b', ...
+func varName(n int) string {
+	name := string(rune('a' + n%26))
+	if n >= 26 {
+		name += strings.Repeat("'", n/26)
+	}
+	return name
+}
@@ -0,0 +1,87 @@
+package typecheck
+
+// Helper function that makes a and b the same type by binding the unknown types in them.
+// It reports false if they cannot be, in which case some variables may already be bound.
+func unify(a, b Type) bool {
+	a, b = prune(a), prune(b)
+	if va, ok := a.(*Var); ok {
+		if vb, ok := b.(*Var); ok && va == vb {
+			return true
+		}
+		// a type cannot contain itself, e.g. a = [a]
+		if occurs(va, b) {
+			return false
+		}
+		lower(b, va.level)
+		va.instance = b
+		return true
+	}
+	if _, ok := b.(*Var); ok {
+		return unify(b, a)
+	}
+
+	switch a := a.(type) {
+	case *Basic:
+		bb, ok := b.(*Basic)
+		return ok && a.Name == bb.Name
+	case *Array:
+		ba, ok := b.(*Array)
+		return ok && unify(a.Element, ba.Element)
+	case *Hash:
+		bh, ok := b.(*Hash)
+		return ok && unify(a.Key, bh.Key) && unify(a.Value, bh.Value)
+	case *Function:
+		bf, ok := b.(*Function)
+		if !ok || len(a.Params) != len(bf.Params) || a.Variadic != bf.Variadic {
+			return false
+		}
+		for i := range a.Params {
+			if !unify(a.Params[i], bf.Params[i]) {
+				return false
+			}
+		}
+		return unify(a.Result, bf.Result)
+	}
+	return false
+}
+
+// Helper function that moves the variables in t out to level, when t becomes what a Var of that
+// level stands for, so that they are not generalized before that Var is
+func lower(t Type, level int) {
+	switch t := prune(t).(type) {
+	case *Var:
+		if t.level > level {
+			t.level = level
+		}
+	case *Array:
+		lower(t.Element, level)
+	case *Hash:
+		lower(t.Key, level)
+		lower(t.Value, level)
+	case *Function:
+		for _, p := range t.Params {
+			lower(p, level)
+		}
+		lower(t.Result, level)
+	}
+}
+
+// Helper function that reports whether v appears in t
+func occurs(v *Var, t Type) bool {
+	switch t := prune(t).(type) {
+	case *Var:
+		return t == v
+	case *Array:
+		return occurs(v, t.Element)
+	case *Hash:
+		return occurs(v, t.Key) || occurs(v, t.Value)
+	case *Function:
+		for _, p := range t.Params {
+			if occurs(v, p) {
+				return true
+			}
+		}
+		return occurs(v, t.Result)
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package vet
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// ConfigFile is the name of the file that monkey vet reads its Config from when none is given
+const ConfigFile = ".monkeyvet.json"
+
+// A Config turns rules on and off. It is read from JSON that names the rules to change:
+//
+//	{"rules": {"shadow": false, "unused-param": false}}
+//
+// Rules that are not named are enabled.
+type Config struct {
+	Rules map[string]bool `json:"rules"`
+}
+
+// Enabled reports whether the rule is on. A nil Config enables every rule.
+func (c *Config) Enabled(rule string) bool {
+	if c == nil {
+		return true
+	}
+	enabled, ok := c.Rules[rule]
+	return !ok || enabled
+}
+
+// LoadConfig reads a Config from the named file
+func LoadConfig(name string) (*Config, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	config := &Config{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	for rule := range config.Rules {
+		if lookupRule(rule) == nil {
+			return nil, fmt.Errorf("%s: unknown rule %s", name, rule)
+		}
+	}
+	return config, nil
+}
+
+// Helper function that finds a rule by name
+func lookupRule(name string) *Rule {
+	for _, rule := range Rules {
+		if rule.Name == name {
+			return rule
+		}
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+package vet
+
+import (
+	"monkey/ast"
+	"monkey/diag"
+	"monkey/optimize"
+	"monkey/token"
+	"strings"
+)
+
+/** Rules from other passes **/
+
+func (v *vetter) macro() {
+	v.diags = append(v.diags, v.expanded...)
+}
+
+// resolver.Resolve reports undefined names as errors and shadowing as warnings, and nothing else
+func (v *vetter) undefined() {
+	for _, d := range v.resolved {
+		if d.Severity == diag.Error {
+			v.diags = append(v.diags, d)
+		}
+	}
+}
+
+func (v *vetter) shadow() {
+	for _, d := range v.resolved {
+		if d.Severity == diag.Warning {
+			v.diags = append(v.diags, d)
+		}
+	}
+}
+
+func (v *vetter) unreachable() {
+	v.diags = append(v.diags, optimize.Unreachable(v.program)...)
+}
+
+/** Unused names **/
+
+func (v *vetter) unusedLet() {
+	used := v.uses()
+	ast.Inspect(v.program, func(node ast.Node) bool {
+		if let, ok := node.(*ast.LET_Statement); ok && let.Name != nil {
+			v.reportUnused(let.Name, used, "%s declared and not used")
+		}
+		return true
+	})
+}
+
+func (v *vetter) unusedParam() {
+	used := v.uses()
+	ast.Inspect(v.program, func(node ast.Node) bool {
+		if fn, ok := node.(*ast.FunctionLiteral); ok {
+			for _, p := range fn.Parameters {
+				v.reportUnused(p.Name, used, "parameter %s is not used")
+			}
+		}
+		return true
+	})
+}
+
+// Helper function that reports the names a pattern binds that are never used. Names starting
+// with an underscore are meant to be unused.
+func (v *vetter) reportUnused(pattern ast.Pattern, used map[*ast.Identifier]bool, format string) {
+	for _, id := range ast.BoundIdentifiers(pattern) {
+		if !used[id] && !strings.HasPrefix(id.Value, "_") {
+			v.diags.Warnf(id.Token.Pos, format, id.Value)
+		}
+	}
+}
+
+// Helper function that finds the declarations that some identifier refers to
+func (v *vetter) uses() map[*ast.Identifier]bool {
+	used := map[*ast.Identifier]bool{}
+	ast.Inspect(v.program, func(node ast.Node) bool {
+		if id, ok := node.(*ast.Identifier); ok && id.Decl != nil && id.Decl != id {
+			used[id.Decl] = true
+		}
+		return true
+	})
+	return used
+}
+
+/** Suspicious expressions **/
+
+func (v *vetter) selfCompare() {
+	ast.Inspect(v.program, func(node ast.Node) bool {
+		e, ok := node.(*ast.INFIX_Expression)
+		if !ok {
+			return true
+		}
+		var always bool
+		switch e.Token.Type {
+		case token.EQ:
+			always = true
+		case token.NOT_EQ, token.LT, token.GT:
+			always = false
+		default:
+			return true
+		}
+		// a call may give a different value each time
+		if ast.Equal(e.Left, e.Right) && !hasCall(e.Left) {
+			v.diags.Warnf(e.Token.Pos, "comparison of %s with itself is always %t", e.Left.Node_String(), always)
+		}
+		return true
+	})
+}
+
+func (v *vetter) identicalBranches() {
+	ast.Inspect(v.program, func(node ast.Node) bool {
+		if e, ok := node.(*ast.IF_Expression); ok && e.Alternative != nil && ast.Equal(e.Consequence, e.Alternative) {
+			v.diags.Warnf(e.Token.Pos, "both branches of the if-expression are the same")
+		}
+		return true
+	})
+}
+
+// An if without else is null when its condition is false, which is only wanted when its value
+// is thrown away
+func (v *vetter) missingElse() {
+	used := valuesUsed(v.program)
+	ast.Inspect(v.program, func(node ast.Node) bool {
+		if e, ok := node.(*ast.IF_Expression); ok && e.Alternative == nil && used[e] {
+			v.diags.Warnf(e.Token.Pos, "if-expression without else is used as a value")
+		}
+		return true
+	})
+}
+
+// Helper function that finds the if-expressions whose value is used: by a let, a return, an
+// operator, a call or a match, or as the result of a function or of an if whose value is used
+func valuesUsed(program *ast.Program) map[*ast.IF_Expression]bool {
+	used := map[*ast.IF_Expression]bool{}
+	var value func(expr ast.Expression)
+	// the value of a block is that of its last statement
+	result := func(block *ast.BlockStatement) {
+		if block == nil || len(block.Statemens) == 0 {
+			return
+		}
+		if s, ok := block.Statemens[len(block.Statemens)-1].(*ast.EXPRESSION_Statement); ok {
+			value(s.Expression)
+		}
+	}
+	value = func(expr ast.Expression) {
+		if e, ok := expr.(*ast.IF_Expression); ok {
+			used[e] = true
+			result(e.Consequence)
+			result(e.Alternative)
+		}
+	}
+	ast.Inspect(program, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.LET_Statement:
+			value(n.Value)
+		case *ast.RETURN_Statement:
+			value(n.ReturnValue)
+		case *ast.PREFIX_Expression:
+			value(n.Right)
+		case *ast.INFIX_Expression:
+			value(n.Left)
+			value(n.Right)
+		case *ast.IF_Expression:
+			value(n.Condition)
+		case *ast.CALL_Expression:
+			value(n.Function)
+			for _, arg := range n.Arguments {
+				value(arg)
+			}
+		case *ast.KEYWORD_Argument:
+			value(n.Value)
+		case *ast.Parameter:
+			value(n.Default)
+		case *ast.MATCH_Expression:
+			value(n.Subject)
+			for _, arm := range n.Arms {
+				value(arm.Guard)
+				value(arm.Body)
+			}
+		case *ast.FunctionLiteral:
+			result(n.Body)
+		}
+		return true
+	})
+	return used
+}
+
+// Helper function that reports whether expr contains a call
+func hasCall(expr ast.Expression) bool {
+	found := false
+	ast.Inspect(expr, func(node ast.Node) bool {
+		if _, ok := node.(*ast.CALL_Expression); ok {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+/** Calls **/
+
+// A function is known when it is called directly, or through a name bound by `let f = fn...`
+func (v *vetter) argCount() {
+	known := map[*ast.Identifier]*ast.FunctionLiteral{}
+	ast.Inspect(v.program, func(node ast.Node) bool {
+		if let, ok := node.(*ast.LET_Statement); ok {
+			id, isName := let.Name.(*ast.Identifier)
+			fn, isFunction := let.Value.(*ast.FunctionLiteral)
+			if isName && isFunction {
+				known[id] = fn
+			}
+		}
+		return true
+	})
+	ast.Inspect(v.program, func(node ast.Node) bool {
+		call, ok := node.(*ast.CALL_Expression)
+		if !ok {
+			return true
+		}
+		var fn *ast.FunctionLiteral
+		switch f := call.Function.(type) {
+		case *ast.FunctionLiteral:
+			fn = f
+		case *ast.Identifier:
+			if f.Decl != nil {
+				fn = known[f.Decl]
+			}
+		}
+		if fn == nil {
+			return true
+		}
+		if _, err := fn.BindArguments(call.Arguments); err != nil {
+			v.diags.Warnf(ast.Pos(call), "%v in call to %s", err, call.Function.Node_String())
+		}
+		return true
+	})
+}
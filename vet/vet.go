@@ -0,0 +1,133 @@
+// Package vet finds code in a Monkey program that parses, and may even run, but is likely not
+// what was meant: unused names, comparisons of a value with itself, ifs whose branches do the
+// same thing, and so on.
+//
+// Every rule can be turned off in a Config, and a `// vet:ignore` comment silences the rules
+// it names, or all of them, on its own line, or on the next line when the comment stands alone:
+//
+//	let unused = 1; // vet:ignore unused-let
+//	// vet:ignore
+//	if (x == x) { 1 } else { 1 }
+package vet
+
+import (
+	"monkey/ast"
+	"monkey/diag"
+	"monkey/macro"
+	"monkey/resolver"
+	"sort"
+	"strings"
+)
+
+// A Rule is one kind of mistake vet looks for
+type Rule struct {
+	Name string
+	Doc  string
+	run  func(v *vetter)
+}
+
+// Rules lists every rule, in the order their issues are reported at the same position
+var Rules = []*Rule{
+	{Name: "macro", Doc: "macros that cannot be expanded", run: (*vetter).macro},
+	{Name: "undefined", Doc: "names that are not defined, or used before their definition", run: (*vetter).undefined},
+	{Name: "shadow", Doc: "declarations that shadow a variable of an enclosing scope", run: (*vetter).shadow},
+	{Name: "unused-let", Doc: "names bound by let that are never used", run: (*vetter).unusedLet},
+	{Name: "unused-param", Doc: "parameters that are never used", run: (*vetter).unusedParam},
+	{Name: "self-compare", Doc: "comparisons of an expression with itself, e.g. x == x", run: (*vetter).selfCompare},
+	{Name: "identical-branches", Doc: "if-expressions whose branches are the same", run: (*vetter).identicalBranches},
+	{Name: "missing-else", Doc: "if-expressions without else whose value is used", run: (*vetter).missingElse},
+	{Name: "arg-count", Doc: "calls to a known function with arguments that do not fit its parameters", run: (*vetter).argCount},
+	{Name: "unreachable", Doc: "code that can never run", run: (*vetter).unreachable},
+}
+
+// An Issue is a diagnostic reported by a rule
+type Issue struct {
+	Rule string
+	*diag.Diagnostic
+}
+
+// Vet runs the rules that config enables over program, parsed from src, and returns the issues
+// that no `// vet:ignore` comment silences, sorted by position. The rules see the program after
+// macro.Expand, which changes it, and resolver.Resolve, which fills in the Decl of every identifier.
+func Vet(program *ast.Program, src []byte, config *Config) []*Issue {
+	v := &vetter{program: program, expanded: macro.Expand(program)}
+	v.resolved = resolver.Resolve(program)
+	ignored := ignoreComments(src)
+	issues := []*Issue{}
+	for _, rule := range Rules {
+		if !config.Enabled(rule.Name) {
+			continue
+		}
+		v.diags = nil
+		rule.run(v)
+		for _, d := range v.diags {
+			if !ignored.silences(d.Pos.Line, rule.Name) {
+				issues = append(issues, &Issue{Rule: rule.Name, Diagnostic: d})
+			}
+		}
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Pos.Offset < issues[j].Pos.Offset
+	})
+	return issues
+}
+
+type vetter struct {
+	program  *ast.Program
+	expanded diag.List // What macro.Expand reported, which the macro rule passes on
+	resolved diag.List // What resolver.Resolve reported, which the undefined and shadow rules pass on
+	diags    diag.List // What the running rule reports
+}
+
+/** Ignore comments **/
+
+// The rules silenced on each line; an empty list silences them all
+type ignores map[int][]string
+
+func (ig ignores) silences(line int, rule string) bool {
+	rules, ok := ig[line]
+	if !ok {
+		return false
+	}
+	if len(rules) == 0 {
+		return true
+	}
+	for _, r := range rules {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// Helper function that finds the `// vet:ignore` comments in src. Monkey has no string
+// literals, so every `//` starts a comment.
+func ignoreComments(src []byte) ignores {
+	ig := ignores{}
+	for i, line := range strings.Split(string(src), "\n") {
+		at := strings.Index(line, "//")
+		if at < 0 {
+			continue
+		}
+		text := strings.TrimSpace(line[at+2:])
+		if !strings.HasPrefix(text, "vet:ignore") {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(text, "vet:ignore"))
+		rules := []string{}
+		for _, f := range fields {
+			rules = append(rules, strings.Split(strings.Trim(f, ","), ",")...)
+		}
+		// lines count from 1; a comment alone on its line is about the next one
+		target := i + 1
+		if strings.TrimSpace(line[:at]) == "" {
+			target++
+		}
+		if existing, ok := ig[target]; ok && len(existing) == 0 || len(rules) == 0 {
+			ig[target] = []string{}
+		} else {
+			ig[target] = append(existing, rules...)
+		}
+	}
+	return ig
+}
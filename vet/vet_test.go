@@ -0,0 +1,140 @@
+package vet
+
+import (
+	"monkey/lexer"
+	"monkey/parser"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Helper function that vets src with config and returns the issues as `line:column rule: message`
+func vet(t *testing.T, src string, config *Config) []string {
+	t.Helper()
+	ps := parser.New(lexer.New(src))
+	program := ps.ParseProgram()
+	if errs := ps.Errors(); len(errs) > 0 {
+		t.Fatalf("parsing %q: %s", src, errs)
+	}
+	var got []string
+	for _, issue := range Vet(program, []byte(src), config) {
+		got = append(got, issue.Pos.String()+" "+issue.Rule+": "+issue.Msg)
+	}
+	return got
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []string
+	}{
+		{"let x = 1; x", nil},
+		{"y", []string{"1:1 undefined: undefined: y"}},
+		{"let x = 1; fn(x) { x }", []string{
+			"1:5 unused-let: x declared and not used",
+			"1:15 shadow: x shadows the declaration at 1:5",
+		}},
+		{"let x = 1;", []string{"1:5 unused-let: x declared and not used"}},
+		{"let [a, _b] = c; a", []string{"1:15 undefined: undefined: c"}},
+		{"fn(a, b) { a }", []string{"1:7 unused-param: parameter b is not used"}},
+		{"fn(_a) { 1 }", nil},
+		{"let x = 1; x == x", []string{"1:14 self-compare: comparison of x with itself is always true"}},
+		{"let x = 1; x + 1 < x + 1", []string{"1:18 self-compare: comparison of (x+1) with itself is always false"}},
+		{"let f = fn() { 1 }; f() == f()", nil},
+		{"let x = 1; if (x) { 1 } else { 1 }", []string{"1:12 identical-branches: both branches of the if-expression are the same"}},
+		{"let x = 1; if (x) { 1 } else { 2 }", nil},
+		{"let x = 1; let y = if (x) { 1 }; y", []string{"1:20 missing-else: if-expression without else is used as a value"}},
+		{"let x = 1; if (x) { 1 }; x", nil},
+		{"let f = fn(x) { if (x) { 1 } }; f(1)", []string{"1:17 missing-else: if-expression without else is used as a value"}},
+		{"let f = fn(a) { a }; f(1, 2)", []string{"1:22 arg-count: wrong number of arguments: want 1, got 2 in call to f"}},
+		{"let f = fn(a) { a }; f(b: 1)", []string{"1:22 arg-count: unexpected keyword argument b in call to f"}},
+		{"fn(a, b) { a + b }(1)", []string{"1:1 arg-count: wrong number of arguments: want 2, got 1 in call to fn(a, b) { (a+b); }"}},
+		{"let f = fn() { return 1; 2 }; f()", []string{"1:26 unreachable: unreachable code after return"}},
+	}
+	for _, tt := range tests {
+		if got := vet(t, tt.src, nil); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q:\n got %q\nwant %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestIgnoreComments(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []string
+	}{
+		{"let unused = 1; // vet:ignore unused-let", nil},
+		{"let unused = 1; // vet:ignore", nil},
+		{"let unused = 1; // vet:ignore shadow", []string{"1:5 unused-let: unused declared and not used"}},
+		{"let unused = 1; // vet:ignore shadow,unused-let", nil},
+		{"let unused = 1; // vet:ignore shadow, unused-let", nil},
+		{"// vet:ignore\nlet unused = 1;", nil},
+		{"// vet:ignore unused-let\nlet unused = 1;\nlet other = 2;", []string{"3:5 unused-let: other declared and not used"}},
+		// a comment alone on its line is about the next line, not its own
+		{"let a = 1; a\n// vet:ignore\n\nlet unused = 1;", []string{"4:5 unused-let: unused declared and not used"}},
+		{"let unused = 1; // a comment", []string{"1:5 unused-let: unused declared and not used"}},
+		{"let x = 1; if (x == x) { 1 } else { 1 } // vet:ignore self-compare", []string{
+			"1:12 identical-branches: both branches of the if-expression are the same",
+		}},
+	}
+	for _, tt := range tests {
+		if got := vet(t, tt.src, nil); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q:\n got %q\nwant %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestConfig(t *testing.T) {
+	src := "let x = 1; fn(x, p) { x == x }"
+	config := &Config{Rules: map[string]bool{"shadow": false, "unused-param": false, "self-compare": true}}
+	want := []string{
+		"1:5 unused-let: x declared and not used",
+		"1:25 self-compare: comparison of x with itself is always true",
+	}
+	if got := vet(t, src, config); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+	if got := vet(t, src, &Config{}); len(got) != 4 {
+		t.Errorf("an empty config enables every rule, got %q", got)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	config, err := LoadConfig(write("ok.json", `{"rules": {"shadow": false, "unused-let": true}}`))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if config.Enabled("shadow") || !config.Enabled("unused-let") || !config.Enabled("arg-count") {
+		t.Errorf("wrong rules enabled by %v", config.Rules)
+	}
+	var none *Config
+	if !none.Enabled("shadow") {
+		t.Errorf("a nil Config disables shadow")
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{write("unknown.json", `{"rules": {"no-such-rule": false}}`), "unknown rule no-such-rule"},
+		{write("bad.json", `{"rules": [}`), "bad.json: invalid character"},
+		{write("kind.json", `{"rules": {"shadow": "off"}}`), "cannot unmarshal string"},
+		{filepath.Join(dir, "missing.json"), "no such file"},
+	}
+	for _, tt := range tests {
+		if _, err := LoadConfig(tt.path); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got error %v, want one containing %q", filepath.Base(tt.path), err, tt.want)
+		}
+	}
+}